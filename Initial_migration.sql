@@ -8,3 +8,111 @@ CREATE TABLE IF NOT EXISTS log_entries (
 -- Indexes to support common queries
 CREATE INDEX IF NOT EXISTS idx_log_entries_level ON log_entries(level);
 CREATE INDEX IF NOT EXISTS idx_log_entries_timestamp ON log_entries(timestamp);
+
+-- Supports keyset pagination ordered by (timestamp DESC, id DESC)
+CREATE INDEX IF NOT EXISTS idx_log_entries_timestamp_id ON log_entries(timestamp DESC, id DESC);
+
+-- Canonical severity (OpenTelemetry SeverityNumber of the level range)
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS severity SMALLINT;
+
+UPDATE log_entries
+SET severity = CASE lower(level)
+                   WHEN 'trace' THEN 1
+                   WHEN 'debug' THEN 5
+                   WHEN 'dbg' THEN 5
+                   WHEN 'warn' THEN 13
+                   WHEN 'warning' THEN 13
+                   WHEN 'error' THEN 17
+                   WHEN 'err' THEN 17
+                   WHEN 'fatal' THEN 21
+                   WHEN 'critical' THEN 21
+                   WHEN 'crit' THEN 21
+                   WHEN 'alert' THEN 21
+                   WHEN 'emerg' THEN 21
+                   WHEN 'emergency' THEN 21
+                   WHEN 'panic' THEN 21
+                   ELSE 9
+               END
+WHERE severity IS NULL;
+
+-- Canonicalize known spellings, unknown legacy levels keep their text
+UPDATE log_entries
+SET level = CASE severity
+                WHEN 1 THEN 'trace'
+                WHEN 5 THEN 'debug'
+                WHEN 9 THEN 'info'
+                WHEN 13 THEN 'warn'
+                WHEN 17 THEN 'error'
+                WHEN 21 THEN 'fatal'
+            END
+WHERE lower(level) IN ('trace', 'debug', 'dbg', 'info', 'information', 'informational', 'notice',
+                       'warn', 'warning', 'error', 'err', 'fatal', 'critical', 'crit', 'alert',
+                       'emerg', 'emergency', 'panic')
+  AND level <> CASE severity
+                   WHEN 1 THEN 'trace'
+                   WHEN 5 THEN 'debug'
+                   WHEN 9 THEN 'info'
+                   WHEN 13 THEN 'warn'
+                   WHEN 17 THEN 'error'
+                   WHEN 21 THEN 'fatal'
+               END;
+
+ALTER TABLE log_entries ALTER COLUMN severity SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_severity ON log_entries(severity);
+
+-- Structured attributes, jsonb_path_ops serves the @> containment filters
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_attributes ON log_entries USING GIN (attributes jsonb_path_ops);
+
+-- Provenance of an entry
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS service TEXT NOT NULL DEFAULT '';
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS host TEXT NOT NULL DEFAULT '';
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS environment TEXT NOT NULL DEFAULT '';
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS version TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_service_timestamp ON log_entries(service, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_log_entries_host ON log_entries(host);
+
+-- Trace correlation (W3C Trace Context IDs as lower-case hex)
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS trace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS span_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_trace_id ON log_entries(trace_id, timestamp) WHERE trace_id <> '';
+CREATE INDEX IF NOT EXISTS idx_log_entries_span_id ON log_entries(span_id) WHERE span_id <> '';
+
+-- Full-text search over messages
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, message)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_search_vector ON log_entries USING GIN (search_vector);
+
+-- Trigram index so ILIKE and regular-expression message filters can use an index
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_message_trgm ON log_entries USING GIN (message gin_trgm_ops);
+
+-- Duplicate suppression: repeats of a message fingerprint within the
+-- ingest dedup window are counted on one row. Existing rows keep an empty
+-- fingerprint, it is computed by the application.
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS fingerprint TEXT NOT NULL DEFAULT '';
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS occurrences INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS first_seen TIMESTAMPTZ;
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ;
+
+UPDATE log_entries
+SET first_seen = COALESCE(first_seen, timestamp),
+    last_seen = COALESCE(last_seen, timestamp)
+WHERE first_seen IS NULL OR last_seen IS NULL;
+
+ALTER TABLE log_entries ALTER COLUMN first_seen SET NOT NULL;
+ALTER TABLE log_entries ALTER COLUMN last_seen SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_fingerprint ON log_entries(fingerprint, timestamp DESC) WHERE fingerprint <> '';
+
+-- Entries re-imported from the archive. Archiving and retention leave them
+-- alone until ARCHIVE_RESTORE_HOLD has passed since the restore.
+ALTER TABLE log_entries ADD COLUMN IF NOT EXISTS restored_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_log_entries_restored_at ON log_entries(restored_at) WHERE restored_at IS NOT NULL;
@@ -0,0 +1,108 @@
+-- Converts log_entries into a table range-partitioned by timestamp.
+-- Run once after Initial_migration.sql. Existing rows are copied into
+-- monthly partitions; the application's partition manager
+-- (PARTITION_ENABLED=true) keeps creating partitions ahead of time using
+-- PARTITION_INTERVAL (daily or monthly) from then on.
+
+BEGIN;
+
+ALTER TABLE log_entries RENAME TO log_entries_unpartitioned;
+
+-- Index names are schema-wide, free them for the new table
+ALTER INDEX IF EXISTS log_entries_pkey RENAME TO log_entries_unpartitioned_pkey;
+ALTER INDEX IF EXISTS idx_log_entries_level RENAME TO idx_log_entries_unpartitioned_level;
+ALTER INDEX IF EXISTS idx_log_entries_timestamp RENAME TO idx_log_entries_unpartitioned_timestamp;
+ALTER INDEX IF EXISTS idx_log_entries_timestamp_id RENAME TO idx_log_entries_unpartitioned_timestamp_id;
+ALTER INDEX IF EXISTS idx_log_entries_severity RENAME TO idx_log_entries_unpartitioned_severity;
+ALTER INDEX IF EXISTS idx_log_entries_attributes RENAME TO idx_log_entries_unpartitioned_attributes;
+ALTER INDEX IF EXISTS idx_log_entries_service_timestamp RENAME TO idx_log_entries_unpartitioned_service_timestamp;
+ALTER INDEX IF EXISTS idx_log_entries_host RENAME TO idx_log_entries_unpartitioned_host;
+ALTER INDEX IF EXISTS idx_log_entries_trace_id RENAME TO idx_log_entries_unpartitioned_trace_id;
+ALTER INDEX IF EXISTS idx_log_entries_span_id RENAME TO idx_log_entries_unpartitioned_span_id;
+ALTER INDEX IF EXISTS idx_log_entries_search_vector RENAME TO idx_log_entries_unpartitioned_search_vector;
+ALTER INDEX IF EXISTS idx_log_entries_message_trgm RENAME TO idx_log_entries_unpartitioned_message_trgm;
+ALTER INDEX IF EXISTS idx_log_entries_fingerprint RENAME TO idx_log_entries_unpartitioned_fingerprint;
+ALTER INDEX IF EXISTS idx_log_entries_restored_at RENAME TO idx_log_entries_unpartitioned_restored_at;
+
+-- The partition key must be part of the primary key
+CREATE TABLE log_entries (
+                             id BIGINT GENERATED ALWAYS AS IDENTITY,
+                             level TEXT NOT NULL,
+                             severity SMALLINT NOT NULL,
+                             message TEXT NOT NULL,
+                             timestamp TIMESTAMPTZ NOT NULL,
+                             attributes JSONB NOT NULL DEFAULT '{}'::jsonb,
+                             service TEXT NOT NULL DEFAULT '',
+                             host TEXT NOT NULL DEFAULT '',
+                             environment TEXT NOT NULL DEFAULT '',
+                             version TEXT NOT NULL DEFAULT '',
+                             trace_id TEXT NOT NULL DEFAULT '',
+                             span_id TEXT NOT NULL DEFAULT '',
+                             search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple'::regconfig, message)) STORED,
+                             fingerprint TEXT NOT NULL DEFAULT '',
+                             occurrences INTEGER NOT NULL DEFAULT 1,
+                             first_seen TIMESTAMPTZ NOT NULL,
+                             last_seen TIMESTAMPTZ NOT NULL,
+                             restored_at TIMESTAMPTZ,
+                             PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+
+-- Catches rows outside every partition instead of rejecting them
+CREATE TABLE log_entries_default PARTITION OF log_entries DEFAULT;
+
+-- One partition per month of existing data, up to the current month
+DO $$
+DECLARE
+    month_start TIMESTAMPTZ;
+    last_month  TIMESTAMPTZ := date_trunc('month', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+BEGIN
+    SELECT date_trunc('month', min(timestamp) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+    INTO month_start
+    FROM log_entries_unpartitioned;
+
+    month_start := COALESCE(LEAST(month_start, last_month), last_month);
+
+    WHILE month_start <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF log_entries FOR VALUES FROM (%L) TO (%L)',
+            'log_entries_p' || to_char(month_start AT TIME ZONE 'UTC', 'YYYYMM'),
+            month_start,
+            month_start + interval '1 month'
+        );
+        month_start := month_start + interval '1 month';
+    END LOOP;
+END
+$$;
+
+-- Indexes on the parent are created on every partition
+CREATE INDEX IF NOT EXISTS idx_log_entries_id ON log_entries(id);
+CREATE INDEX IF NOT EXISTS idx_log_entries_level ON log_entries(level);
+CREATE INDEX IF NOT EXISTS idx_log_entries_timestamp_id ON log_entries(timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_log_entries_severity ON log_entries(severity);
+CREATE INDEX IF NOT EXISTS idx_log_entries_attributes ON log_entries USING GIN (attributes jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_log_entries_service_timestamp ON log_entries(service, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_log_entries_host ON log_entries(host);
+CREATE INDEX IF NOT EXISTS idx_log_entries_trace_id ON log_entries(trace_id, timestamp) WHERE trace_id <> '';
+CREATE INDEX IF NOT EXISTS idx_log_entries_span_id ON log_entries(span_id) WHERE span_id <> '';
+CREATE INDEX IF NOT EXISTS idx_log_entries_search_vector ON log_entries USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_log_entries_message_trgm ON log_entries USING GIN (message gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_log_entries_fingerprint ON log_entries(fingerprint, timestamp DESC) WHERE fingerprint <> '';
+CREATE INDEX IF NOT EXISTS idx_log_entries_restored_at ON log_entries(restored_at) WHERE restored_at IS NOT NULL;
+
+-- Keep the existing IDs, then continue the identity after them
+INSERT INTO log_entries (id, level, severity, message, timestamp, attributes,
+                         service, host, environment, version, trace_id, span_id,
+                         fingerprint, occurrences, first_seen, last_seen, restored_at)
+    OVERRIDING SYSTEM VALUE
+SELECT id, level, severity, message, timestamp, attributes,
+       service, host, environment, version, trace_id, span_id,
+       fingerprint, occurrences, first_seen, last_seen, restored_at
+FROM log_entries_unpartitioned;
+
+SELECT setval(pg_get_serial_sequence('log_entries', 'id'),
+              COALESCE((SELECT max(id) FROM log_entries), 0) + 1,
+              false);
+
+DROP TABLE log_entries_unpartitioned;
+
+COMMIT;
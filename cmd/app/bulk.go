@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// bulkFieldKeys lists, per entry field, the document keys of the
+// Elasticsearch _bulk API that are tried in order. ECS names (as sent by
+// Filebeat) come first, then common flat names (Fluent Bit, Logstash).
+var bulkFieldKeys = []struct {
+	field string
+	keys  []string
+}{
+	{"timestamp", []string{"@timestamp", "timestamp", "time"}},
+	{"level", []string{"log.level", "level", "severity", "loglevel"}},
+	{"message", []string{"message", "msg", "log"}},
+	{"service", []string{"service.name", "service", "app"}},
+	{"host", []string{"host.name", "host.hostname", "hostname", "host"}},
+	{"environment", []string{"service.environment", "environment", "env"}},
+	{"version", []string{"service.version", "version"}},
+	{"trace_id", []string{"trace.id", "trace_id"}},
+	{"span_id", []string{"span.id", "span_id"}},
+}
+
+// bulkCompatVersion is the Elasticsearch version reported to clients.
+// Filebeat refuses clusters older than itself unless
+// output.elasticsearch.allow_older_versions is true (the default since 8.9).
+const bulkCompatVersion = "8.17.0"
+
+// bulkInfo mirrors the response of GET / of Elasticsearch, reduced to what
+// clients look at before sending _bulk requests. Filebeat additionally
+// needs setup.ilm.enabled: false and setup.template.enabled: false, since
+// neither index lifecycle nor template APIs are implemented.
+type bulkInfo struct {
+	Name        string `json:"name"`
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number                           string `json:"number"`
+		BuildFlavor                      string `json:"build_flavor"`
+		MinimumWireCompatibilityVersion  string `json:"minimum_wire_compatibility_version"`
+		MinimumIndexCompatibilityVersion string `json:"minimum_index_compatibility_version"`
+	} `json:"version"`
+	Tagline string `json:"tagline"`
+}
+
+func newBulkInfo() bulkInfo {
+	info := bulkInfo{Name: "log-api", ClusterName: "log-api", Tagline: "You Know, for Search"}
+	info.Version.Number = bulkCompatVersion
+	info.Version.BuildFlavor = "default"
+	info.Version.MinimumWireCompatibilityVersion = "7.17.0"
+	info.Version.MinimumIndexCompatibilityVersion = "7.0.0"
+	return info
+}
+
+// bulkError is the error object of a failed bulk item or request.
+type bulkError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// bulkItemResult is the per-document result, keyed by its action in the
+// response as Elasticsearch does.
+type bulkItemResult struct {
+	Index       string     `json:"_index"`
+	ID          string     `json:"_id,omitempty"`
+	Version     int        `json:"_version,omitempty"`
+	Result      string     `json:"result,omitempty"`
+	Status      int        `json:"status"`
+	SeqNo       *int       `json:"_seq_no,omitempty"`
+	PrimaryTerm int        `json:"_primary_term,omitempty"`
+	Error       *bulkError `json:"error,omitempty"`
+}
+
+// bulkResponse mirrors the response of POST /_bulk.
+type bulkResponse struct {
+	Took   int64                       `json:"took"`
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+func (r *bulkResponse) fail(action string, item bulkItemResult, status int, errType, reason string) {
+	item.Status = status
+	item.Error = &bulkError{Type: errType, Reason: reason}
+	r.Items = append(r.Items, map[string]bulkItemResult{action: item})
+	r.Errors = true
+}
+
+// ingestBulk processes the action/document pairs of a _bulk body. Only
+// index and create actions are supported since log entries are immutable;
+// update and delete items fail individually. Entries are saved in chunks
+// of chunkSize; if a chunk fails, it and all later items get a 503 so the
+// client retries exactly those. Documents Postgres rejects as invalid
+// data get a 400 on their own and do not fail the others. An error means
+// the request itself is malformed (e.g. a broken action line), which
+// Elasticsearch answers with 400 as a whole.
+func ingestBulk(ctx context.Context, body []byte, defaultIndex string, chunkSize int, save saveChunkFunc) (bulkResponse, error) {
+	start := time.Now()
+	resp := bulkResponse{Items: []map[string]bulkItemResult{}}
+
+	type pending struct {
+		item  int // index into resp.Items
+		entry *model.LogEntry
+	}
+	var chunk []pending
+	saveFailed := false
+
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		entries := make([]*model.LogEntry, len(chunk))
+		for i, p := range chunk {
+			entries[i] = p.entry
+		}
+
+		// Once a chunk failed, later chunks are not attempted.
+		skipped := saveFailed
+		errs := make([]error, len(chunk))
+		if !skipped {
+			if _, err := save(ctx, entries); err != nil {
+				if _, ok := dataError(err); ok && len(entries) > 1 {
+					// Find the offending documents, the others are stored.
+					for i, e := range entries {
+						_, errs[i] = save(ctx, []*model.LogEntry{e})
+					}
+				} else {
+					for i := range errs {
+						errs[i] = err
+					}
+				}
+			}
+		}
+		for i, p := range chunk {
+			msg, invalid := dataError(errs[i])
+			for action, item := range resp.Items[p.item] {
+				switch {
+				case invalid:
+					item.Status = fiber.StatusBadRequest
+					item.Error = &bulkError{Type: "document_parsing_exception", Reason: msg}
+					resp.Errors = true
+				case skipped || errs[i] != nil:
+					item.Status = fiber.StatusServiceUnavailable
+					item.Error = &bulkError{Type: "unavailable_shards_exception", Reason: "failed to save log entry"}
+					resp.Errors = true
+				default:
+					seqNo := p.entry.ID
+					item.ID = strconv.Itoa(p.entry.ID)
+					item.Version = 1
+					item.Result = "created"
+					item.Status = fiber.StatusCreated
+					item.SeqNo = &seqNo
+					item.PrimaryTerm = 1
+				}
+				resp.Items[p.item][action] = item
+			}
+			if errs[i] != nil && !invalid {
+				saveFailed = true
+			}
+		}
+		chunk = chunk[:0]
+	}
+
+	lines := bufio.NewScanner(bytes.NewReader(body))
+	lines.Buffer(nil, len(body)+1)
+	for lines.Scan() {
+		line := bytes.TrimSpace(lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		action, meta, err := parseBulkAction(line)
+		if err != nil {
+			return resp, err
+		}
+		item := bulkItemResult{Index: meta.Index, ID: meta.ID}
+		if item.Index == "" {
+			item.Index = defaultIndex
+		}
+
+		if action == "delete" {
+			resp.fail(action, item, fiber.StatusBadRequest, "action_request_validation_exception", "delete is not supported, log entries are append-only")
+			continue
+		}
+
+		if !lines.Scan() {
+			return resp, fmt.Errorf("%s action without a document", action)
+		}
+		if action == "update" {
+			resp.fail(action, item, fiber.StatusBadRequest, "action_request_validation_exception", "update is not supported, log entries are append-only")
+			continue
+		}
+
+		entry, err := bulkDocument(lines.Bytes())
+		if err != nil {
+			resp.fail(action, item, fiber.StatusBadRequest, "document_parsing_exception", err.Error())
+			continue
+		}
+
+		resp.Items = append(resp.Items, map[string]bulkItemResult{action: item})
+		chunk = append(chunk, pending{item: len(resp.Items) - 1, entry: entry})
+		if len(chunk) >= chunkSize {
+			flush()
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return resp, err
+	}
+	flush()
+
+	resp.Took = time.Since(start).Milliseconds()
+	return resp, nil
+}
+
+type bulkActionMeta struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// parseBulkAction parses an action line such as {"index":{"_index":"logs"}}.
+func parseBulkAction(line []byte) (string, bulkActionMeta, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil || len(raw) != 1 {
+		return "", bulkActionMeta{}, fmt.Errorf("malformed action/metadata line, expected a single action object")
+	}
+
+	var meta bulkActionMeta
+	for action, v := range raw {
+		switch action {
+		case "index", "create", "update", "delete":
+		default:
+			return "", meta, fmt.Errorf("unknown action [%s]", action)
+		}
+		if err := json.Unmarshal(v, &meta); err != nil {
+			return "", meta, fmt.Errorf("malformed metadata of action [%s]", action)
+		}
+		return action, meta, nil
+	}
+	return "", meta, nil
+}
+
+// bulkDocument maps a bulk document onto a log entry. The fields of
+// bulkFieldKeys are taken out of the document, everything else becomes
+// attributes. Documents without a level are stored as info.
+func bulkDocument(line []byte) (*model.LogEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("document must be a JSON object")
+	}
+
+	values := map[string]any{}
+	for _, f := range bulkFieldKeys {
+		for _, key := range f.keys {
+			if v, ok := takeScalar(doc, key); ok {
+				values[f.field] = v
+				break
+			}
+		}
+	}
+
+	str := func(field string) string {
+		switch v := values[field].(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+		return ""
+	}
+
+	input := CreateLogEntryRequest{
+		Level:       str("level"),
+		Message:     strings.TrimRight(str("message"), "\r\n"), // container log lines keep their newline
+		Service:     str("service"),
+		Host:        str("host"),
+		Environment: str("environment"),
+		Version:     str("version"),
+		TraceID:     str("trace_id"),
+		SpanID:      str("span_id"),
+	}
+	if input.Level == "" {
+		input.Level = model.SeverityInfo.String()
+	}
+	if len(doc) > 0 {
+		input.Attributes = doc
+	}
+
+	ts, err := parseImportTimestamp(values["timestamp"], "")
+	if err != nil {
+		return nil, err
+	}
+	input.Timestamp = ts
+
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	entry := input.toModel()
+	return &entry, nil
+}
+
+// takeScalar removes the string, number or boolean at key from doc and
+// returns it. key is an exact key or a dotted path into nested objects;
+// objects left empty by the removal are dropped as well.
+func takeScalar(doc map[string]any, key string) (any, bool) {
+	if v, ok := doc[key]; ok {
+		if !isScalar(v) {
+			return nil, false
+		}
+		delete(doc, key)
+		return v, true
+	}
+
+	head, rest, nested := strings.Cut(key, ".")
+	if !nested {
+		return nil, false
+	}
+	obj, ok := doc[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	v, ok := takeScalar(obj, rest)
+	if ok && len(obj) == 0 {
+		delete(doc, head)
+	}
+	return v, ok
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, json.Number, bool:
+		return true
+	}
+	return false
+}
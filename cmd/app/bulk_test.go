@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+func TestBulkDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		want      model.LogEntry
+		wantAttrs map[string]any
+		wantErr   bool
+	}{
+		{
+			name: "ECS",
+			doc: `{"@timestamp":"2024-05-01T10:00:00.5Z","message":"GET /\n","log":{"level":"WARN","logger":"http"},
+				"service":{"name":"api","environment":"prod","version":"1.2"},"host":{"name":"web-1"},
+				"trace":{"id":"5b8efff798038103d269b633813fc60c"},"span":{"id":"eee19b7ec3c1b174"}}`,
+			want: model.LogEntry{
+				Message: "GET /", Level: "warn", Service: "api", Environment: "prod", Version: "1.2", Host: "web-1",
+				Timestamp: time.Date(2024, time.May, 1, 10, 0, 0, 5e8, time.UTC),
+				TraceID:   "5b8efff798038103d269b633813fc60c", SpanID: "eee19b7ec3c1b174",
+			},
+			wantAttrs: map[string]any{"log": map[string]any{"logger": "http"}},
+		},
+		{
+			name:      "flat names",
+			doc:       `{"time":1714557600,"msg":"started","severity":"error","app":"worker","hostname":"h1","env":"dev","pid":7}`,
+			want:      model.LogEntry{Message: "started", Level: "error", Service: "worker", Host: "h1", Environment: "dev", Timestamp: time.Unix(1714557600, 0).UTC()},
+			wantAttrs: map[string]any{"pid": json.Number("7")},
+		},
+		{
+			name:      "ECS name preferred over flat name",
+			doc:       `{"@timestamp":"2024-05-01T10:00:00Z","message":"m","service":{"name":"ecs"},"app":"flat"}`,
+			want:      model.LogEntry{Message: "m", Level: "info", Service: "ecs", Timestamp: time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)},
+			wantAttrs: map[string]any{"app": "flat"},
+		},
+		{
+			name:      "non-scalar is not taken",
+			doc:       `{"@timestamp":"2024-05-01T10:00:00Z","message":"m","host":{"ip":["10.0.0.1"]}}`,
+			want:      model.LogEntry{Message: "m", Level: "info", Timestamp: time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)},
+			wantAttrs: map[string]any{"host": map[string]any{"ip": []any{"10.0.0.1"}}},
+		},
+		{name: "not an object", doc: `["message"]`, wantErr: true},
+		{name: "no message", doc: `{"level":"info"}`, wantErr: true},
+		{name: "invalid level", doc: `{"message":"m","level":"loud"}`, wantErr: true},
+		{name: "NaN timestamp", doc: `{"message":"m","@timestamp":"NaN"}`, wantErr: true},
+		{name: "invalid trace id", doc: `{"message":"m","trace_id":"xyz"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bulkDocument([]byte(tt.doc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bulkDocument() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Message != tt.want.Message || got.Level != tt.want.Level || got.Service != tt.want.Service ||
+				got.Host != tt.want.Host || got.Environment != tt.want.Environment || got.Version != tt.want.Version ||
+				got.TraceID != tt.want.TraceID || got.SpanID != tt.want.SpanID {
+				t.Errorf("bulkDocument() = %+v\nwant %+v", got, tt.want)
+			}
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("timestamp = %s, want %s", got.Timestamp, tt.want.Timestamp)
+			}
+			if !reflect.DeepEqual(got.Attributes, tt.wantAttrs) {
+				t.Errorf("attributes = %#v\nwant %#v", got.Attributes, tt.wantAttrs)
+			}
+		})
+	}
+}
+
+func TestTakeScalar(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    any
+		wantOK  bool
+		wantDoc map[string]any
+	}{
+		{
+			name: "exact key", key: "log.level", want: "exact", wantOK: true,
+			wantDoc: map[string]any{"log": map[string]any{"level": "nested"}, "a": map[string]any{"b": "x", "c": "y"}, "obj": map[string]any{"k": "v"}},
+		},
+		{
+			name: "nested leaf", key: "a.b", want: "x", wantOK: true,
+			wantDoc: map[string]any{"log.level": "exact", "log": map[string]any{"level": "nested"}, "a": map[string]any{"c": "y"}, "obj": map[string]any{"k": "v"}},
+		},
+		{
+			name: "emptied object is dropped", key: "obj.k", want: "v", wantOK: true,
+			wantDoc: map[string]any{"log.level": "exact", "log": map[string]any{"level": "nested"}, "a": map[string]any{"b": "x", "c": "y"}},
+		},
+		{name: "object is not a scalar", key: "obj"},
+		{name: "missing", key: "a.z"},
+		{name: "path through a scalar", key: "a.b.c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]any{
+				"log.level": "exact",
+				"log":       map[string]any{"level": "nested"},
+				"a":         map[string]any{"b": "x", "c": "y"},
+				"obj":       map[string]any{"k": "v"},
+			}
+			before := len(doc)
+			got, ok := takeScalar(doc, tt.key)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("takeScalar(%q) = %v, %t, want %v, %t", tt.key, got, ok, tt.want, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(doc, tt.wantDoc) {
+				t.Errorf("document = %#v\nwant %#v", doc, tt.wantDoc)
+			}
+			if !ok && len(doc) != before {
+				t.Errorf("takeScalar(%q) changed the document without a match", tt.key)
+			}
+		})
+	}
+}
+
+func TestIngestBulk(t *testing.T) {
+	errInvalid := &pgconn.PgError{Code: "22P05", Message: "unsupported Unicode escape sequence"}
+	errDown := errors.New("connection refused")
+
+	// save fails like Postgres: a chunk with an invalid document or while
+	// the database is down fails as a whole.
+	nextID := 0
+	var calls int
+	save := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+		calls++
+		for _, e := range entries {
+			switch e.Message {
+			case "bad":
+				return 0, errInvalid
+			case "down":
+				return 0, errDown
+			}
+		}
+		for _, e := range entries {
+			nextID++
+			e.ID = nextID
+		}
+		return int64(len(entries)), nil
+	}
+
+	body := strings.Join([]string{
+		`{"index":{"_index":"logs"}}`, `{"message":"a"}`,
+		`{"create":{}}`, `{"message":"bad"}`,
+		`{"delete":{"_id":"1"}}`,
+		`{"update":{"_id":"1"}}`, `{"doc":{}}`,
+		`{"index":{}}`, `{"level":"info"}`,
+		`{"index":{}}`, `{"message":"c"}`,
+		`{"index":{}}`, `{"message":"down"}`,
+		`{"index":{}}`, `{"message":"e"}`,
+		"",
+	}, "\n")
+
+	resp, err := ingestBulk(context.Background(), []byte(body), "default", 2, save)
+	if err != nil {
+		t.Fatalf("ingestBulk() error = %v", err)
+	}
+	if !resp.Errors {
+		t.Error("errors = false, want true")
+	}
+
+	want := []struct {
+		action string
+		index  string
+		status int
+	}{
+		{"index", "logs", 201},
+		{"create", "default", 400}, // invalid data, the rest of its chunk is stored
+		{"delete", "default", 400},
+		{"update", "default", 400},
+		{"index", "default", 400}, // rejected before saving
+		{"index", "default", 503}, // same chunk as the failing one
+		{"index", "default", 503},
+		{"index", "default", 503}, // not attempted after the failure
+	}
+	if len(resp.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(resp.Items), len(want))
+	}
+	for i, w := range want {
+		item, ok := resp.Items[i][w.action]
+		if !ok || item.Index != w.index || item.Status != w.status {
+			t.Errorf("item %d = %+v, want %s on %s with status %d", i, resp.Items[i], w.action, w.index, w.status)
+		}
+	}
+	if item := resp.Items[0]["index"]; item.ID != "1" || item.Result != "created" {
+		t.Errorf("first item = %+v, want created with _id 1", item)
+	}
+	if item := resp.Items[1]["create"]; item.Error == nil || item.Error.Reason != errInvalid.Message {
+		t.Errorf("invalid item error = %+v", item.Error)
+	}
+	// The first chunk once and per document, the second chunk once.
+	if calls != 4 {
+		t.Errorf("save called %d times, want 4", calls)
+	}
+}
+
+func TestIngestBulkMalformed(t *testing.T) {
+	save := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+		return int64(len(entries)), nil
+	}
+
+	for _, body := range []string{
+		`{"index":{}}` + "\n",
+		`not json` + "\n" + `{"message":"a"}`,
+		`{"index":{},"create":{}}` + "\n" + `{"message":"a"}`,
+		`{"upsert":{}}` + "\n" + `{"message":"a"}`,
+		`{"index":"logs"}` + "\n" + `{"message":"a"}`,
+	} {
+		if _, err := ingestBulk(context.Background(), []byte(body), "default", 10, save); err == nil {
+			t.Errorf("ingestBulk(%q) accepted a malformed request", body)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/archive"
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// errUnknownCommand is returned by runCommand for names it does not handle.
+var errUnknownCommand = errors.New("unknown command")
+
+// runCommand runs a one-off subcommand such as "archive" instead of the
+// HTTP server. It stops early on SIGINT or SIGTERM.
+func runCommand(name string, args []string, cfg config.Config, logRepo *repo.Repo) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch name {
+	case "archive":
+		return runArchive(ctx, args, cfg, logRepo)
+	case "restore":
+		return runRestore(ctx, args, cfg, logRepo)
+	case "import":
+		return runImport(ctx, args, cfg, logRepo)
+	default:
+		return fmt.Errorf("%w %q, expected serve, archive, restore or import", errUnknownCommand, name)
+	}
+}
+
+// runArchive archives every complete day before -before, which defaults to
+// now minus ARCHIVE_AFTER.
+func runArchive(ctx context.Context, args []string, cfg config.Config, logRepo *repo.Repo) error {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	before := fs.String("before", "", "archive days before this date (YYYY-MM-DD or RFC3339), default now - ARCHIVE_AFTER")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-cfg.Archive.After)
+	if *before != "" {
+		t, err := parseCommandTime(*before)
+		if err != nil {
+			return fmt.Errorf("invalid -before: %w", err)
+		}
+		cutoff = t
+	}
+
+	manifests, err := archive.NewArchiver(logRepo, cfg).ArchiveBefore(ctx, cutoff)
+	total := 0
+	for _, m := range manifests {
+		total += m.Entries
+	}
+	log.Printf("[info] archived %d entries in %d files to %s", total, len(manifests), cfg.Archive.Dir)
+	return err
+}
+
+// runRestore re-imports the archived days between -from and -to inclusive.
+func runRestore(ctx context.Context, args []string, cfg config.Config, logRepo *repo.Repo) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fromStr := fs.String("from", "", "first day to restore (YYYY-MM-DD), required")
+	toStr := fs.String("to", "", "last day to restore (YYYY-MM-DD), defaults to -from")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *fromStr == "" {
+		return errors.New("restore: -from is required")
+	}
+	from, err := parseCommandTime(*fromStr)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to := from
+	if *toStr != "" {
+		if to, err = parseCommandTime(*toStr); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+
+	result, err := archive.NewArchiver(logRepo, cfg).Restore(ctx, from, to)
+	log.Printf("[info] restored %d entries from %d files (%d duplicates skipped)", result.Restored, result.Files, result.Skipped)
+	return err
+}
+
+// parseCommandTime accepts a date (YYYY-MM-DD, UTC midnight) or RFC3339.
+func parseCommandTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
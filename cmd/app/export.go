@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// exportFlushEvery is the number of rows written between flushes of the
+// response, i.e. roughly the size of one transfer chunk.
+const exportFlushEvery = 500
+
+// exportFormat describes one supported download format.
+type exportFormat struct {
+	name        string
+	contentType string
+	extension   string
+}
+
+var (
+	exportCSV    = exportFormat{name: "csv", contentType: "text/csv; charset=utf-8", extension: "csv"}
+	exportNDJSON = exportFormat{name: "ndjson", contentType: "application/x-ndjson", extension: "ndjson"}
+)
+
+// negotiateExportFormat picks the export format from an explicit format=
+// parameter, falling back to the Accept header and finally to NDJSON.
+func negotiateExportFormat(param, accept string) (exportFormat, bool) {
+	switch strings.ToLower(param) {
+	case "csv":
+		return exportCSV, true
+	case "ndjson", "jsonl":
+		return exportNDJSON, true
+	case "":
+	default:
+		return exportFormat{}, false
+	}
+
+	if strings.Contains(accept, "text/csv") && !strings.Contains(accept, "application/x-ndjson") {
+		return exportCSV, true
+	}
+	return exportNDJSON, true
+}
+
+// streamExport writes all entries matching params to w in the given format.
+func streamExport(ctx context.Context, logRepo *repo.Repo, params repo.SearchParams, format exportFormat, w *bufio.Writer) error {
+	var write func(*model.LogEntry) error
+	var flush func() error
+
+	switch format.name {
+	case exportCSV.name:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{
+			"id", "level", "severity", "message", "timestamp", "attributes",
+			"service", "host", "environment", "version",
+			"trace_id", "span_id",
+			"fingerprint", "occurrences", "first_seen", "last_seen",
+		}); err != nil {
+			return err
+		}
+		write = func(e *model.LogEntry) error {
+			attrs, err := json.Marshal(e.Attributes)
+			if err != nil {
+				return err
+			}
+			return cw.Write([]string{
+				strconv.Itoa(e.ID),
+				e.Level,
+				strconv.Itoa(int(e.Severity)),
+				e.Message,
+				e.Timestamp.UTC().Format(time.RFC3339Nano),
+				string(attrs),
+				e.Service,
+				e.Host,
+				e.Environment,
+				e.Version,
+				e.TraceID,
+				e.SpanID,
+				e.Fingerprint,
+				strconv.Itoa(e.Occurrences),
+				e.FirstSeen.UTC().Format(time.RFC3339Nano),
+				e.LastSeen.UTC().Format(time.RFC3339Nano),
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+	default:
+		enc := json.NewEncoder(w)
+		write = func(e *model.LogEntry) error {
+			return enc.Encode(e)
+		}
+		flush = w.Flush
+	}
+
+	written := 0
+	err := logRepo.Export(ctx, params, func(e *model.LogEntry) error {
+		if err := write(e); err != nil {
+			return err
+		}
+		written++
+		// Flushing pushes a chunk to the client; it fails once the client is gone.
+		if written%exportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// importProgressEvery is the interval between progress lines of an import.
+const importProgressEvery = 2 * time.Second
+
+// importCheckpoint records how far an import got. It is rewritten after
+// every committed batch, so a rerun continues after the last batch.
+type importCheckpoint struct {
+	File      string    `json:"file"`
+	Offset    int64     `json:"offset"`  // byte offset after the last committed record
+	Records   int       `json:"records"` // records read up to Offset
+	Imported  int64     `json:"imported"`
+	Rejected  int       `json:"rejected"`
+	Done      bool      `json:"done"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func readCheckpoint(path string) (*importCheckpoint, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp importCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// write stores the checkpoint atomically next to path.
+func (cp *importCheckpoint) write(path string) error {
+	cp.UpdatedAt = time.Now().UTC()
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// importSource yields the records of an input file.
+type importSource interface {
+	// next returns the next record and the byte offset just after it.
+	// A non-nil recErr rejects this record only; err ends the import and
+	// is io.EOF at the end of the input.
+	next() (rec map[string]any, offset int64, recErr error, err error)
+}
+
+// ndjsonSource reads one JSON object per line. Blank lines yield a nil record.
+type ndjsonSource struct {
+	r       *bufio.Reader
+	offset  int64
+	maxLine int
+}
+
+func (s *ndjsonSource) next() (map[string]any, int64, error, error) {
+	line, tooLong, err := s.readLine()
+	if len(line) == 0 && !tooLong && err != nil {
+		return nil, s.offset, nil, err
+	}
+
+	line = bytes.TrimSpace(line)
+	switch {
+	case tooLong || len(line) > s.maxLine:
+		return nil, s.offset, fmt.Errorf("line exceeds %d bytes", s.maxLine), nil
+	case len(line) == 0:
+		return nil, s.offset, nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var rec map[string]any
+	if err := dec.Decode(&rec); err != nil || rec == nil {
+		return nil, s.offset, errors.New("invalid JSON object"), nil
+	}
+	return rec, s.offset, nil, nil
+}
+
+// readLine reads the next line like readLine does for the NDJSON endpoint,
+// but keeps the terminator so that offset advances by the bytes consumed.
+// A line longer than maxLine (plus a CRLF) is discarded while it is read.
+func (s *ndjsonSource) readLine() (line []byte, tooLong bool, err error) {
+	for {
+		part, err := s.r.ReadSlice('\n')
+		s.offset += int64(len(part))
+		if !tooLong {
+			if len(line)+len(part) > s.maxLine+2 {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, part...)
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return line, tooLong, err
+		}
+	}
+}
+
+// csvSource reads CSV rows keyed by the header row.
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+	base   int64 // file offset the reader started at
+}
+
+func (s *csvSource) next() (map[string]any, int64, error, error) {
+	row, err := s.r.Read()
+	offset := s.base + s.r.InputOffset()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			return nil, offset, perr.Err, nil
+		}
+		return nil, offset, nil, err
+	}
+
+	rec := make(map[string]any, len(row))
+	for i, v := range row {
+		rec[s.header[i]] = v
+	}
+	return rec, offset, nil, nil
+}
+
+// importOptions are the flags of the import command.
+type importOptions struct {
+	path            string
+	format          string
+	mapping         columnMapping
+	timeLayout      string
+	delimiter       rune
+	checkpoint      string
+	restart         bool
+	batchSize       int
+	maxLine         int
+	extraAttributes bool
+	noDedup         bool
+}
+
+// runImport backfills log entries from an NDJSON or CSV file.
+func runImport(ctx context.Context, args []string, cfg config.Config, logRepo *repo.Repo) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: ndjson or csv (default: by file extension)")
+	mapping := fs.String("map", "", "column mapping, e.g. message=msg,timestamp=@timestamp,level=severity")
+	timeLayout := fs.String("time-format", "", "Go time layout for timestamps (default: auto-detect)")
+	delimiter := fs.String("delimiter", ",", "CSV field delimiter")
+	checkpoint := fs.String("checkpoint", "", "checkpoint file (default: <file>.checkpoint)")
+	restart := fs.Bool("restart", false, "ignore an existing checkpoint and start from the beginning")
+	batchSize := fs.Int("batch", cfg.Ingest.StreamChunkSize, "entries per insert")
+	extra := fs.String("extra", "attributes", "unmapped columns: attributes or ignore")
+	noDedup := fs.Bool("no-dedup", false, "insert every record, bypassing duplicate suppression")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: import [flags] <file>")
+	}
+
+	opts := importOptions{
+		path:       fs.Arg(0),
+		timeLayout: *timeLayout,
+		checkpoint: *checkpoint,
+		restart:    *restart,
+		batchSize:  *batchSize,
+		maxLine:    cfg.Ingest.MaxLineBytes,
+		noDedup:    *noDedup,
+	}
+
+	opts.format = strings.ToLower(*format)
+	if opts.format == "" {
+		switch strings.ToLower(filepath.Ext(opts.path)) {
+		case ".csv":
+			opts.format = "csv"
+		default:
+			opts.format = "ndjson"
+		}
+	}
+	if opts.format == "jsonl" {
+		opts.format = "ndjson"
+	}
+	if opts.format != "ndjson" && opts.format != "csv" {
+		return fmt.Errorf("invalid -format %q, expected ndjson or csv", *format)
+	}
+
+	m, err := parseColumnMapping(*mapping)
+	if err != nil {
+		return err
+	}
+	opts.mapping = m
+
+	if d := []rune(*delimiter); len(d) == 1 {
+		opts.delimiter = d[0]
+	} else {
+		return fmt.Errorf("invalid -delimiter %q, expected a single character", *delimiter)
+	}
+
+	switch *extra {
+	case "attributes":
+		opts.extraAttributes = true
+	case "ignore":
+	default:
+		return fmt.Errorf("invalid -extra %q, expected attributes or ignore", *extra)
+	}
+
+	if opts.batchSize <= 0 {
+		return fmt.Errorf("invalid -batch %d", opts.batchSize)
+	}
+	if opts.checkpoint == "" {
+		opts.checkpoint = opts.path + ".checkpoint"
+	}
+
+	return importFile(ctx, opts, logRepo)
+}
+
+func importFile(ctx context.Context, opts importOptions, logRepo *repo.Repo) error {
+	absPath, err := filepath.Abs(opts.path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(opts.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	cp := &importCheckpoint{File: absPath}
+	if !opts.restart {
+		saved, err := readCheckpoint(opts.checkpoint)
+		if err != nil {
+			return err
+		}
+		if saved != nil {
+			if saved.File != absPath || saved.Offset > info.Size() {
+				return fmt.Errorf("checkpoint %s belongs to another file or a larger one, use -restart", opts.checkpoint)
+			}
+			if saved.Done {
+				log.Printf("[info] %s was already imported (%d entries), use -restart to import it again", opts.path, saved.Imported)
+				return nil
+			}
+			cp = saved
+			log.Printf("[info] resuming %s at record %d (byte %d)", opts.path, cp.Records, cp.Offset)
+		}
+	}
+
+	var src importSource
+	switch opts.format {
+	case "csv":
+		header, err := readCSVHeader(f, opts.delimiter)
+		if err != nil {
+			return err
+		}
+		// The header row is consumed but not counted as a record.
+		if cp.Offset == 0 {
+			cp.Offset = header.end
+		}
+		if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		r := csv.NewReader(bufio.NewReaderSize(f, 64*1024))
+		r.Comma = opts.delimiter
+		r.FieldsPerRecord = len(header.names)
+		r.ReuseRecord = true
+		src = &csvSource{r: r, header: header.names, base: cp.Offset}
+	default:
+		if _, err := f.Seek(cp.Offset, io.SeekStart); err != nil {
+			return err
+		}
+		src = &ndjsonSource{r: bufio.NewReaderSize(f, 64*1024), offset: cp.Offset, maxLine: opts.maxLine}
+	}
+
+	batch := make([]*model.LogEntry, 0, opts.batchSize)
+	reported := 0
+	lastProgress := time.Now()
+
+	progress := func(final bool) {
+		pct := 100.0
+		if info.Size() > 0 {
+			pct = float64(cp.Offset) / float64(info.Size()) * 100
+		}
+		prefix := "import"
+		if final {
+			prefix = "import finished"
+		}
+		log.Printf("[info] %s: %.1f%%, %d records, %d imported, %d rejected", prefix, pct, cp.Records, cp.Imported, cp.Rejected)
+	}
+
+	var saveOpts []repo.BatchOption
+	if opts.noDedup {
+		saveOpts = append(saveOpts, repo.SkipDedup())
+	}
+
+	flush := func(offset int64) error {
+		if len(batch) > 0 {
+			n, err := logRepo.SaveBatch(ctx, batch, false, saveOpts...)
+			if err != nil {
+				return err
+			}
+			cp.Imported += n
+			batch = batch[:0]
+		}
+		cp.Offset = offset
+		return cp.write(opts.checkpoint)
+	}
+
+	reject := func(record int, msg string) {
+		cp.Rejected++
+		if reported < maxReportedLineErrors {
+			log.Printf("[warning] record %d rejected: %s", record, msg)
+		} else if reported == maxReportedLineErrors {
+			log.Printf("[warning] further rejected records are not reported")
+		}
+		reported++
+	}
+
+	records := cp.Records
+	var offset int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rec, next, recErr, err := src.next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("read %s: %w", opts.path, err)
+		}
+		offset = next
+		records++
+
+		switch {
+		case recErr != nil:
+			reject(records, recErr.Error())
+		case rec == nil:
+			// blank line
+		default:
+			input, err := opts.mapping.toRequest(rec, opts.timeLayout, opts.extraAttributes)
+			if err == nil {
+				err = input.validate()
+			}
+			if err != nil {
+				reject(records, err.Error())
+				break
+			}
+			logEntry := input.toModel()
+			batch = append(batch, &logEntry)
+		}
+
+		if len(batch) >= opts.batchSize {
+			cp.Records = records
+			if err := flush(offset); err != nil {
+				return err
+			}
+		}
+
+		if time.Since(lastProgress) >= importProgressEvery {
+			progress(false)
+			lastProgress = time.Now()
+		}
+	}
+
+	cp.Records = records
+	if offset == 0 {
+		offset = cp.Offset
+	}
+	cp.Done = true
+	if err := flush(offset); err != nil {
+		return err
+	}
+	progress(true)
+	return nil
+}
+
+// csvHeader is the header row of a CSV file and the offset after it.
+type csvHeader struct {
+	names []string
+	end   int64
+}
+
+func readCSVHeader(f *os.File, delimiter rune) (csvHeader, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return csvHeader{}, err
+	}
+	r := csv.NewReader(f)
+	r.Comma = delimiter
+	names, err := r.Read()
+	if err != nil {
+		return csvHeader{}, fmt.Errorf("read CSV header: %w", err)
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF"))
+	}
+	return csvHeader{names: names, end: r.InputOffset()}, nil
+}
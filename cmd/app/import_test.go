@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sourceStep is one expected result of importSource.next.
+type sourceStep struct {
+	rec    bool // a record is returned
+	offset int64
+	recErr bool
+}
+
+func drainSource(t *testing.T, src importSource, want []sourceStep) {
+	t.Helper()
+
+	for i, step := range want {
+		rec, offset, recErr, err := src.next()
+		if err != nil {
+			t.Fatalf("next() #%d error = %v", i, err)
+		}
+		if (rec != nil) != step.rec || offset != step.offset || (recErr != nil) != step.recErr {
+			t.Fatalf("next() #%d = %v, %d, %v, want record %t, offset %d, rejected %t",
+				i, rec, offset, recErr, step.rec, step.offset, step.recErr)
+		}
+	}
+	if _, _, _, err := src.next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("next() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestNDJSONSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []sourceStep
+	}{
+		{
+			name:  "records and blank lines",
+			input: "{\"a\":1}\n\n{\"b\":2}\r\n",
+			want:  []sourceStep{{rec: true, offset: 8}, {offset: 9}, {rec: true, offset: 18}},
+		},
+		{
+			name:  "last line without newline",
+			input: "{\"a\":1}\n{\"b\":2}",
+			want:  []sourceStep{{rec: true, offset: 8}, {rec: true, offset: 15}},
+		},
+		{
+			name:  "invalid lines are rejected",
+			input: "not json\n[1,2]\nnull\n{\"a\":1}\n",
+			want:  []sourceStep{{offset: 9, recErr: true}, {offset: 15, recErr: true}, {offset: 20, recErr: true}, {rec: true, offset: 28}},
+		},
+		{
+			name:  "long line is skipped",
+			input: "{\"message\":\"" + strings.Repeat("x", 100) + "\"}\n{\"a\":1}\n",
+			want:  []sourceStep{{offset: 115, recErr: true}, {rec: true, offset: 123}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The smallest bufio buffer forces long lines across several reads.
+			src := &ndjsonSource{r: bufio.NewReaderSize(strings.NewReader(tt.input), 16), maxLine: 32}
+			drainSource(t, src, tt.want)
+		})
+	}
+}
+
+func TestNDJSONSourceNumbers(t *testing.T) {
+	src := &ndjsonSource{r: bufio.NewReader(strings.NewReader(`{"ts":1714557600123456789}`)), maxLine: 64}
+	rec, _, recErr, err := src.next()
+	if err != nil || recErr != nil {
+		t.Fatalf("next() error = %v, %v", recErr, err)
+	}
+	if got := rec["ts"]; got != any(json.Number("1714557600123456789")) {
+		t.Errorf("ts = %#v, want the exact json.Number", got)
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	input := "level,message\ninfo,started\nerror,\"broken\n"
+	r := csv.NewReader(strings.NewReader(input))
+	header, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read() header error = %v", err)
+	}
+
+	src := &csvSource{r: r, header: header}
+	rec, offset, recErr, err := src.next()
+	if err != nil || recErr != nil {
+		t.Fatalf("next() error = %v, %v", recErr, err)
+	}
+	if rec["level"] != "info" || rec["message"] != "started" || offset != 27 {
+		t.Errorf("next() = %v, %d", rec, offset)
+	}
+
+	if _, _, recErr, err = src.next(); err != nil || recErr == nil {
+		t.Errorf("next() on a broken row = %v, %v, want a rejected record", recErr, err)
+	}
+	if _, _, _, err = src.next(); !errors.Is(err, io.EOF) {
+		t.Errorf("next() at end error = %v, want io.EOF", err)
+	}
+}
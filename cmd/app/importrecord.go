@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importFields lists the entry fields an import column mapping can target.
+var importFields = []string{
+	"level", "message", "timestamp", "attributes",
+	"service", "host", "environment", "version",
+	"trace_id", "span_id",
+}
+
+// columnMapping maps entry fields to source keys: CSV headers, or top-level
+// keys and dotted paths of NDJSON objects.
+type columnMapping map[string]string
+
+// parseColumnMapping parses "message=msg,timestamp=@timestamp". Fields that
+// are not mentioned map to the key of the same name.
+func parseColumnMapping(raw string) (columnMapping, error) {
+	m := columnMapping{}
+	for _, f := range importFields {
+		m[f] = f
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, key, ok := strings.Cut(part, "=")
+		field, key = strings.TrimSpace(field), strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("mapping %q: expected field=column", part)
+		}
+		if _, known := m[field]; !known {
+			return nil, fmt.Errorf("mapping %q: unknown field %q (valid: %s)", part, field, strings.Join(importFields, ", "))
+		}
+		m[field] = key
+	}
+
+	return m, nil
+}
+
+// lookup returns the value of key in rec. An exact key wins over a dotted
+// path into nested objects. path holds the keys that lead to the value.
+func lookup(rec map[string]any, key string) (value any, path []string, ok bool) {
+	if v, ok := rec[key]; ok {
+		return v, []string{key}, true
+	}
+
+	path = strings.Split(key, ".")
+	var cur any = rec
+	for _, p := range path {
+		obj, isObj := cur.(map[string]any)
+		if !isObj {
+			return nil, nil, false
+		}
+		if cur, ok = obj[p]; !ok {
+			return nil, nil, false
+		}
+	}
+	return cur, path, true
+}
+
+// withoutPath returns a copy of obj without the value at path. Objects
+// left empty by the removal are dropped as well.
+func withoutPath(obj map[string]any, path []string) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, v := range obj {
+		out[k] = v
+	}
+	if len(path) == 1 {
+		delete(out, path[0])
+		return out
+	}
+	if child, ok := out[path[0]].(map[string]any); ok {
+		if rest := withoutPath(child, path[1:]); len(rest) > 0 {
+			out[path[0]] = rest
+		} else {
+			delete(out, path[0])
+		}
+	}
+	return out
+}
+
+// toRequest builds a CreateLogEntryRequest from a source record. With
+// extraAttributes, keys not consumed by the mapping become attributes.
+func (m columnMapping) toRequest(rec map[string]any, timeLayout string, extraAttributes bool) (CreateLogEntryRequest, error) {
+	var req CreateLogEntryRequest
+	// remaining is rec without the values taken by the mapping. For dotted
+	// paths only the leaf is removed, the rest of the object is kept.
+	remaining := rec
+	consume := func(path []string) {
+		if extraAttributes {
+			remaining = withoutPath(remaining, path)
+		}
+	}
+
+	str := func(field string) string {
+		v, path, ok := lookup(rec, m[field])
+		if !ok || v == nil {
+			return ""
+		}
+		consume(path)
+		switch v := v.(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		default:
+			b, _ := json.Marshal(v)
+			return string(b)
+		}
+	}
+
+	req.Level = str("level")
+	req.Message = str("message")
+	req.Service = str("service")
+	req.Host = str("host")
+	req.Environment = str("environment")
+	req.Version = str("version")
+	req.TraceID = str("trace_id")
+	req.SpanID = str("span_id")
+
+	if v, path, ok := lookup(rec, m["timestamp"]); ok {
+		consume(path)
+		ts, err := parseImportTimestamp(v, timeLayout)
+		if err != nil {
+			return req, err
+		}
+		req.Timestamp = ts
+	}
+
+	if v, path, ok := lookup(rec, m["attributes"]); ok && v != nil {
+		consume(path)
+		switch v := v.(type) {
+		case map[string]any:
+			req.Attributes = maps.Clone(v) // extra columns are added to it
+		case string:
+			if strings.TrimSpace(v) != "" {
+				if err := json.Unmarshal([]byte(v), &req.Attributes); err != nil {
+					return req, errors.New("attributes must be a JSON object")
+				}
+			}
+		default:
+			return req, errors.New("attributes must be a JSON object")
+		}
+	}
+
+	if extraAttributes {
+		for k, v := range remaining {
+			if s, isStr := v.(string); isStr && s == "" {
+				continue
+			}
+			if req.Attributes == nil {
+				req.Attributes = map[string]any{}
+			}
+			// Explicit attributes win over extra columns of the same name.
+			if _, exists := req.Attributes[k]; !exists {
+				req.Attributes[k] = v
+			}
+		}
+	}
+
+	return req, nil
+}
+
+// importTimeLayouts are tried in order for string timestamps. Layouts
+// without a zone are read as UTC.
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999", // log4j / Python logging
+	"02/Jan/2006:15:04:05 -0700",    // Apache / nginx access logs
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"2006-01-02",
+}
+
+// importStampLayouts lack a year (syslog style); see parseImportTimestamp.
+var importStampLayouts = []string{time.StampNano, time.Stamp}
+
+// parseImportTimestamp accepts the layout given with -time-format, common
+// textual formats and Unix epochs in s, ms, µs or ns (chosen by magnitude).
+func parseImportTimestamp(v any, layout string) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q for layout %q", s, layout)
+		}
+		return t.UTC(), nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return epochTime(float64(n), n), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		// ParseFloat accepts NaN and Inf; int64(f) is undefined for them
+		// and for values beyond the int64 range.
+		if math.IsNaN(f) || math.Abs(f) >= math.MaxInt64 {
+			return time.Time{}, fmt.Errorf("timestamp %q out of range", s)
+		}
+		return epochTime(f, int64(f)), nil
+	}
+
+	for _, l := range importTimeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	for _, l := range importStampLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return stampTime(t, time.Now().UTC()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// stampTime completes a syslog stamp, which carries no year, with the
+// latest year that does not put it in the future, allowing a day of clock
+// skew. Feb 29 goes to the latest leap year.
+func stampTime(t, now time.Time) time.Time {
+	for year := now.Year(); ; year-- {
+		d := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+		if d.Month() == t.Month() && !d.After(now.Add(24*time.Hour)) {
+			return d
+		}
+	}
+}
+
+// epochTime interprets a Unix epoch as seconds, milliseconds, microseconds
+// or nanoseconds depending on its magnitude. n is the integer form of f,
+// used where float64 would lose precision.
+func epochTime(f float64, n int64) time.Time {
+	abs := math.Abs(f)
+	switch {
+	case abs < 1e11:
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	case abs < 1e14:
+		return time.UnixMicro(int64(f * 1e3)).UTC()
+	case abs < 1e17:
+		return time.UnixMicro(n).UTC()
+	default:
+		return time.Unix(0, n).UTC()
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseImportTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      any
+		layout  string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: time.Time{}},
+		{name: "nil", in: nil, want: time.Time{}},
+		{name: "seconds", in: json.Number("1714557600"), want: time.Unix(1714557600, 0)},
+		{name: "fractional seconds", in: "1714557600.5", want: time.Unix(1714557600, 5e8)},
+		{name: "milliseconds", in: "1714557600123", want: time.UnixMilli(1714557600123)},
+		{name: "microseconds", in: "1714557600123456", want: time.UnixMicro(1714557600123456)},
+		{name: "nanoseconds", in: "1714557600123456789", want: time.Unix(0, 1714557600123456789)},
+		{name: "RFC 3339", in: "2024-05-01T10:00:00+02:00", want: time.Date(2024, time.May, 1, 8, 0, 0, 0, time.UTC)},
+		{name: "log4j", in: "2024-05-01 10:00:00,250", want: time.Date(2024, time.May, 1, 10, 0, 0, 25e7, time.UTC)},
+		{name: "layout", in: "01.05.2024", layout: "02.01.2006", want: time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "layout mismatch", in: "2024-05-01", layout: "02.01.2006", wantErr: true},
+		{name: "NaN", in: "NaN", wantErr: true},
+		{name: "Inf", in: "Inf", wantErr: true},
+		{name: "negative Inf", in: "-Inf", wantErr: true},
+		{name: "huge", in: "1e300", wantErr: true},
+		{name: "beyond int64", in: json.Number("99999999999999999999"), wantErr: true},
+		{name: "bool", in: true, wantErr: true},
+		{name: "garbage", in: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportTimestamp(tt.in, tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportTimestamp(%v) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseImportTimestamp(%v) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStampTime(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		stamp string
+		want  time.Time
+	}{
+		{"Mar 10 08:00:00", time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC)},
+		{"Mar 11 06:00:00", time.Date(2025, time.March, 11, 6, 0, 0, 0, time.UTC)}, // clock skew
+		{"Dec 31 23:59:59", time.Date(2024, time.December, 31, 23, 59, 59, 0, time.UTC)},
+		{"Feb 28 10:00:00", time.Date(2025, time.February, 28, 10, 0, 0, 0, time.UTC)},
+		{"Feb 29 10:00:00", time.Date(2024, time.February, 29, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		stamp, err := time.Parse(time.Stamp, tt.stamp)
+		if err != nil {
+			t.Fatalf("time.Parse(%q) error = %v", tt.stamp, err)
+		}
+		if got := stampTime(stamp, now); !got.Equal(tt.want) {
+			t.Errorf("stampTime(%q) = %s, want %s", tt.stamp, got, tt.want)
+		}
+	}
+}
+
+func TestToRequestExtraAttributes(t *testing.T) {
+	mapping, err := parseColumnMapping("message=log.msg,timestamp=@timestamp,service=app")
+	if err != nil {
+		t.Fatalf("parseColumnMapping() error = %v", err)
+	}
+
+	rec := map[string]any{
+		"log":        map[string]any{"msg": "hello", "file": "app.go", "origin": map[string]any{"line": json.Number("7")}},
+		"@timestamp": "2024-05-01T10:00:00Z",
+		"app":        "api",
+		"level":      "info",
+		"user":       "42",
+		"empty":      "",
+		"attributes": map[string]any{"user": "explicit"},
+	}
+
+	req, err := mapping.toRequest(rec, "", true)
+	if err != nil {
+		t.Fatalf("toRequest() error = %v", err)
+	}
+	if req.Message != "hello" || req.Service != "api" || req.Level != "info" {
+		t.Errorf("request message %q, service %q, level %q", req.Message, req.Service, req.Level)
+	}
+	want := map[string]any{
+		"user": "explicit",
+		"log":  map[string]any{"file": "app.go", "origin": map[string]any{"line": json.Number("7")}},
+	}
+	if !reflect.DeepEqual(req.Attributes, want) {
+		t.Errorf("attributes = %#v\nwant %#v", req.Attributes, want)
+	}
+	if _, ok := rec["log"].(map[string]any)["msg"]; !ok {
+		t.Error("toRequest() modified the source record")
+	}
+
+	req, err = mapping.toRequest(rec, "", false)
+	if err != nil {
+		t.Fatalf("toRequest() error = %v", err)
+	}
+	if !reflect.DeepEqual(req.Attributes, map[string]any{"user": "explicit"}) {
+		t.Errorf("attributes without extras = %#v", req.Attributes)
+	}
+}
+
+func TestWithoutPath(t *testing.T) {
+	obj := map[string]any{"a": map[string]any{"b": map[string]any{"c": 1}}, "d": 2}
+	if got := withoutPath(obj, []string{"a", "b", "c"}); !reflect.DeepEqual(got, map[string]any{"d": 2}) {
+		t.Errorf("withoutPath() = %v, want the emptied objects dropped", got)
+	}
+	if got := withoutPath(obj, []string{"a", "x"}); !reflect.DeepEqual(got, obj) {
+		t.Errorf("withoutPath() = %v for a missing path", got)
+	}
+}
@@ -1,26 +1,42 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/julian-richter/ApiTemplate/internal/archive"
 	"github.com/julian-richter/ApiTemplate/internal/config"
 	"github.com/julian-richter/ApiTemplate/internal/db"
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+	"github.com/julian-richter/ApiTemplate/internal/ingest/gelf"
+	"github.com/julian-richter/ApiTemplate/internal/ingest/loki"
+	"github.com/julian-richter/ApiTemplate/internal/ingest/otlp"
+	"github.com/julian-richter/ApiTemplate/internal/ingest/syslog"
+	"github.com/julian-richter/ApiTemplate/internal/jobs/partition"
+	"github.com/julian-richter/ApiTemplate/internal/jobs/retention"
 	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
 	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
 )
 
-// CreateLogEntryRequest represents the request body for creating a log entry
-type CreateLogEntryRequest struct {
-	Level     string    `json:"level"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
-}
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once a shutdown signal arrives.
+const shutdownTimeout = 30 * time.Second
+
+// bodyLimit caps the bodies of the JSON endpoints that read them whole.
+const bodyLimit = fiber.DefaultBodyLimit
 
 func main() {
 	cfg, err := config.Load()
@@ -40,16 +56,102 @@ func main() {
 	// ------------------------------------------------------------
 	// OPTIONAL VALKEY CACHE
 	// ------------------------------------------------------------
-	var logRepo *repo.Repo
+	var repoOpts []repo.RepoOption
 
 	valkeyCli, err := db.NewValkeyClient(cfg)
 	if err != nil {
-		log.Printf("[warning] Valkey cache disabled: %v", err)
-		logRepo = repo.NewRepo(pgPool) // no cache
+		log.Printf("[warning] Valkey cache disabled: %v", err) // no cache
 	} else {
 		defer valkeyCli.Close()
 		log.Printf("[info] Valkey cache enabled")
-		logRepo = repo.NewRepo(pgPool, repo.WithCache(valkeyCli, "app:"))
+		repoOpts = append(repoOpts, repo.WithCache(valkeyCli, "app:"))
+	}
+
+	if cfg.Ingest.DedupWindow > 0 {
+		log.Printf("[info] duplicate suppression enabled (window %s)", cfg.Ingest.DedupWindow)
+		repoOpts = append(repoOpts, repo.WithDedup(cfg.Ingest.DedupWindow))
+	}
+
+	logRepo := repo.NewRepo(pgPool, repoOpts...)
+
+	// ------------------------------------------------------------
+	// SUBCOMMANDS (default: serve)
+	// ------------------------------------------------------------
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		if err := runCommand(os.Args[1], os.Args[2:], cfg, logRepo); err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// ------------------------------------------------------------
+	// BACKGROUND JOBS
+	// ------------------------------------------------------------
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Batchers still hold entries when jobsCtx ends; shutdown waits for
+	// them to save those before the pool closes.
+	var batchers sync.WaitGroup
+	runBatcher := func(b *ingest.Batcher) {
+		batchers.Add(1)
+		go func() {
+			defer batchers.Done()
+			b.Run(jobsCtx)
+		}()
+	}
+
+	partitionMgr := partition.NewManager(pgPool, cfg)
+	if cfg.Partition.Enabled {
+		log.Printf("[info] partition manager enabled (%s, %d ahead)", cfg.Partition.Interval, cfg.Partition.Premake)
+		go partitionMgr.Run(jobsCtx)
+	}
+
+	if cfg.Archive.Enabled {
+		log.Printf("[info] archiver enabled (entries older than %s to %s)", cfg.Archive.After, cfg.Archive.Dir)
+		go archive.NewArchiver(logRepo, cfg).Run(jobsCtx)
+	}
+
+	if cfg.Retention.Enabled {
+		retentionJob, err := retention.NewJob(logRepo, partitionMgr, cfg)
+		if err != nil {
+			log.Fatalf("Invalid retention policies: %v", err)
+		}
+		log.Printf("[info] retention job enabled (%d rules, dry run %t)", len(cfg.Retention.Rules), cfg.Retention.DryRun)
+		go retentionJob.Run(jobsCtx)
+	}
+
+	// ------------------------------------------------------------
+	// SYSLOG RECEIVER
+	// ------------------------------------------------------------
+	if cfg.Syslog.Enabled {
+		saveSyslog := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+			return logRepo.SaveBatch(ctx, entries, false)
+		}
+		batcher := ingest.NewBatcher("syslog", saveSyslog, cfg.Syslog.BatchSize, cfg.Syslog.FlushInterval)
+		runBatcher(batcher)
+
+		if err := syslog.NewServer(cfg, batcher).Start(jobsCtx); err != nil {
+			log.Fatalf("Failed to start syslog receiver: %v", err)
+		}
+		log.Printf("[info] syslog receiver enabled (udp %q, tcp %q)", cfg.Syslog.UDPAddr, cfg.Syslog.TCPAddr)
+	}
+
+	// ------------------------------------------------------------
+	// GELF RECEIVER
+	// ------------------------------------------------------------
+	var gelfBatcher *ingest.Batcher
+	if cfg.Gelf.Enabled {
+		saveGelf := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+			return logRepo.SaveBatch(ctx, entries, false)
+		}
+		gelfBatcher = ingest.NewBatcher("gelf", saveGelf, cfg.Gelf.BatchSize, cfg.Gelf.FlushInterval)
+		runBatcher(gelfBatcher)
+
+		if err := gelf.NewServer(cfg, gelfBatcher).Start(jobsCtx); err != nil {
+			log.Fatalf("Failed to start GELF receiver: %v", err)
+		}
+		log.Printf("[info] GELF receiver enabled (udp %q, http POST /gelf)", cfg.Gelf.UDPAddr)
 	}
 
 	// ------------------------------------------------------------
@@ -59,15 +161,15 @@ func main() {
 		DisableStartupMessage: true,
 		EnablePrintRoutes:     false,
 		ServerHeader:          "ApiTemplate",
+		BodyLimit:             bodyLimit,
+		// Lets /logs/ndjson consume large bodies incrementally. Routes that
+		// read the body whole are wrapped in limitBody.
+		StreamRequestBody: true,
 	})
 
 	// Search endpoint
 	app.Get("/logs/search", func(c *fiber.Ctx) error {
 		maxLimit := 500
-		level := c.Query("level", "")
-		messageContains := c.Query("message_contains", "")
-		sinceStr := c.Query("since", "")
-		untilStr := c.Query("until", "")
 		limit := c.QueryInt("limit", 100)
 		offset := c.QueryInt("offset", 0)
 
@@ -78,64 +180,109 @@ func main() {
 			limit = maxLimit
 		}
 
-		var since, until *time.Time
-
-		if sinceStr != "" {
-			t, err := time.Parse(time.RFC3339, sinceStr)
-			if err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error":   "invalid since timestamp format",
-					"details": sinceStr,
-				})
-			}
-			since = &t
+		params, qerr := parseSearchFilters(c)
+		if qerr != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(qerr.body())
 		}
 
-		if untilStr != "" {
-			t, err := time.Parse(time.RFC3339, untilStr)
-			if err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error":   "invalid until timestamp format",
-					"details": untilStr,
-				})
-			}
-			until = &t
+		if params.After != nil && offset != 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "cursor and offset cannot be combined",
+			})
 		}
 
-		params := repo.SearchParams{
-			Level:           level,
-			MessageContains: messageContains,
-			Since:           since,
-			Until:           until,
-			Limit:           limit,
-			Offset:          offset,
+		params.Limit = limit
+		params.Offset = offset
+
+		totalMode, facetFields, qerr := parseSearchExtras(c)
+		if qerr != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(qerr.body())
 		}
 
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 		entries, err := logRepo.Search(ctx, params)
 		if err != nil {
-			log.Printf("search error: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "search failed",
-			})
-		}
-
-		if len(entries) == 0 {
-			return c.Status(fiber.StatusOK).JSON(fiber.Map{
-				"data":   []model.LogEntry{},
-				"count":  0,
-				"offset": offset,
-				"limit":  limit,
-			})
+			return searchFailed(c, "search error", err)
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		resp := fiber.Map{
 			"data":   entries,
 			"count":  len(entries),
 			"offset": offset,
 			"limit":  limit,
+		}
+
+		if len(entries) == 0 {
+			resp["data"] = []model.LogEntry{}
+		}
+
+		// A full page may have a successor; hand out the keyset cursor for it.
+		if len(entries) == limit && repo.CursorCompatible(params.Sort) {
+			resp["next_cursor"] = repo.CursorFor(entries[len(entries)-1]).Encode()
+		}
+
+		switch totalMode {
+		case totalExact:
+			total, err := logRepo.Count(ctx, params)
+			if err != nil {
+				return searchFailed(c, "search count error", err)
+			}
+			resp["total"] = total
+		case totalEstimate:
+			total, err := logRepo.EstimateCount(ctx, params)
+			if err != nil {
+				return searchFailed(c, "search estimate error", err)
+			}
+			resp["total"] = total
+			resp["total_estimated"] = true
+		}
+
+		if len(facetFields) > 0 {
+			facets := fiber.Map{}
+			for _, field := range facetFields {
+				counts, err := logRepo.Facets(ctx, params, field)
+				if err != nil {
+					return searchFailed(c, "search facet error", err)
+				}
+				facets[field] = counts
+			}
+			resp["facets"] = facets
+		}
+
+		return c.Status(fiber.StatusOK).JSON(resp)
+	})
+
+	// Export search results as a streamed download
+	app.Get("/logs/export", func(c *fiber.Ctx) error {
+		params, qerr := parseSearchFilters(c)
+		if qerr != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(qerr.body())
+		}
+
+		format, ok := negotiateExportFormat(c.Query("format", ""), c.Get(fiber.HeaderAccept))
+		if !ok {
+			return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+				"error":   "unsupported export format",
+				"details": "use format=csv or format=ndjson",
+			})
+		}
+
+		filename := fmt.Sprintf("logs-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format.extension)
+		c.Set(fiber.HeaderContentType, format.contentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+		// The stream writer runs after the handler returns, so it must not
+		// use the request context.
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			defer cancel()
+
+			if err := streamExport(ctx, logRepo, params, format, w); err != nil {
+				log.Printf("export error: %v", err)
+			}
 		})
+		return nil
 	})
 
 	// Get a single log entry
@@ -167,32 +314,49 @@ func main() {
 		return c.JSON(entry)
 	})
 
+	// Delete a single log entry
+	app.Delete("/logs/:id", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid id")
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := logRepo.Delete(ctx, id); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "log entry not found",
+				})
+			}
+
+			log.Printf("delete log entry error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to delete log entry",
+			})
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
 	// Create log entry
-	app.Post("/logs", func(c *fiber.Ctx) error {
+	app.Post("/logs", limitBody(bodyLimit), func(c *fiber.Ctx) error {
 		var input CreateLogEntryRequest
 		if err := c.BodyParser(&input); err != nil {
 			return c.Status(fiber.StatusBadRequest).SendString("invalid body")
 		}
 
+		// Correlate with the caller's trace unless the body names one.
+		input.applyTraceparent(c.Get("traceparent"))
+
 		// Validate required fields
-		if input.Level == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("level is required")
-		}
-		if input.Message == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("message is required")
+		if err := input.validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 		}
 
 		// Create LogEntry from request (ID will be 0 by default, forcing INSERT)
-		logEntry := model.LogEntry{
-			Level:     input.Level,
-			Message:   input.Message,
-			Timestamp: input.Timestamp,
-		}
-
-		// Set timestamp if not provided by client
-		if logEntry.Timestamp.IsZero() {
-			logEntry.Timestamp = time.Now().UTC()
-		}
+		logEntry := input.toModel()
 
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
@@ -207,6 +371,436 @@ func main() {
 		return c.Status(fiber.StatusCreated).JSON(logEntry)
 	})
 
+	// Create many log entries at once
+	app.Post("/logs/batch", limitBody(bodyLimit), func(c *fiber.Ctx) error {
+		var items []json.RawMessage
+		if err := json.Unmarshal(c.Body(), &items); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid body, expected a JSON array")
+		}
+
+		if len(items) == 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("batch is empty")
+		}
+		if len(items) > cfg.Ingest.MaxBatchSize {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error":          "batch too large",
+				"max_batch_size": cfg.Ingest.MaxBatchSize,
+			})
+		}
+
+		returnIDs := c.QueryBool("return_ids", false)
+
+		// Validate every item, invalid ones are reported and skipped.
+		entries := make([]*model.LogEntry, 0, len(items))
+		itemErrors := []batchItemError{}
+		for i, raw := range items {
+			var input CreateLogEntryRequest
+			if err := json.Unmarshal(raw, &input); err != nil {
+				itemErrors = append(itemErrors, batchItemError{Index: i, Error: "invalid entry"})
+				continue
+			}
+			if err := input.validate(); err != nil {
+				itemErrors = append(itemErrors, batchItemError{Index: i, Error: err.Error()})
+				continue
+			}
+			logEntry := input.toModel()
+			entries = append(entries, &logEntry)
+		}
+
+		if len(entries) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"accepted": 0,
+				"rejected": len(itemErrors),
+				"errors":   itemErrors,
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+		defer cancel()
+
+		n, err := logRepo.SaveBatch(ctx, entries, returnIDs)
+		if err != nil {
+			log.Printf("save log batch error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to save log entries",
+			})
+		}
+
+		resp := fiber.Map{
+			"accepted": len(entries),
+			"inserted": n, // below accepted when duplicates collapse
+			"rejected": len(itemErrors),
+			"errors":   itemErrors,
+		}
+		if returnIDs {
+			ids := make([]int, len(entries))
+			for i, e := range entries {
+				ids[i] = e.ID
+			}
+			resp["ids"] = ids
+		}
+
+		status := fiber.StatusCreated
+		if len(itemErrors) > 0 {
+			status = fiber.StatusMultiStatus
+		}
+		return c.Status(status).JSON(resp)
+	})
+
+	// Stream newline-delimited log entries
+	app.Post("/logs/ndjson", func(c *fiber.Ctx) error {
+		if !strings.HasPrefix(c.Get(fiber.HeaderContentType), "application/x-ndjson") {
+			return c.Status(fiber.StatusUnsupportedMediaType).SendString("content type must be application/x-ndjson")
+		}
+
+		body := c.Context().RequestBodyStream()
+		if body == nil {
+			body = bytes.NewReader(c.Body())
+		}
+
+		// Each chunk gets its own deadline, the stream itself may run long.
+		save := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			return logRepo.SaveBatch(ctx, entries, false)
+		}
+
+		summary, err := ingestNDJSON(c.Context(), body, cfg.Ingest.StreamChunkSize, cfg.Ingest.MaxLineBytes, save)
+		if err != nil {
+			log.Printf("ndjson ingest error after %d lines: %v", summary.Lines, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   "failed to ingest log stream",
+				"summary": summary,
+			})
+		}
+
+		status := fiber.StatusOK
+		if summary.Accepted == 0 && summary.Rejected > 0 {
+			status = fiber.StatusBadRequest
+		}
+		return c.Status(status).JSON(summary)
+	})
+
+	// OpenTelemetry OTLP/HTTP logs receiver (ExportLogsServiceRequest)
+	app.Post("/v1/logs", func(c *fiber.Ctx) error {
+		contentType := c.Get(fiber.HeaderContentType)
+
+		payload, status, err := readPushPayload(c, cfg.Ingest.MaxPayloadBytes)
+		if err != nil {
+			return c.Status(status).SendString(err.Error())
+		}
+
+		req, ok, err := otlp.Decode(payload, contentType)
+		if !ok {
+			return c.Status(fiber.StatusUnsupportedMediaType).SendString("content type must be application/x-protobuf or application/json")
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+
+		entries, rejected, message := req.Entries(time.Now().UTC())
+
+		// OTLP clients retry on 503, so a failed save is not lost.
+		if status, msg, err := pushSave(c.Context(), logRepo, entries, cfg.Ingest.StreamChunkSize); err != nil {
+			log.Printf("otlp ingest error: %v", err)
+			return c.Status(status).SendString(msg)
+		}
+
+		resp, respType := otlp.EncodeResponse(contentType, rejected, message)
+		c.Set(fiber.HeaderContentType, respType)
+		return c.Status(fiber.StatusOK).Send(resp)
+	})
+
+	// Loki push API, used by Promtail and Grafana Agent
+	app.Post("/loki/api/v1/push", func(c *fiber.Ctx) error {
+		payload, status, err := readPushPayload(c, cfg.Ingest.MaxPayloadBytes)
+		if err != nil {
+			return c.Status(status).SendString(err.Error())
+		}
+
+		req, ok, err := loki.Decode(payload, c.Get(fiber.HeaderContentType), cfg.Ingest.MaxPayloadBytes)
+		if !ok {
+			return c.Status(fiber.StatusUnsupportedMediaType).SendString("content type must be application/x-protobuf or application/json")
+		}
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+
+		entries, rejected, message := req.Entries(time.Now().UTC())
+
+		// Agents retry on 5xx and drop batches answered with 4xx.
+		if status, msg, err := pushSave(c.Context(), logRepo, entries, cfg.Ingest.StreamChunkSize); err != nil {
+			log.Printf("loki push error: %v", err)
+			return c.Status(status).SendString(msg)
+		}
+
+		// Like Loki, valid lines are kept and the rejected ones reported.
+		if rejected > 0 {
+			return c.Status(fiber.StatusBadRequest).SendString(
+				fmt.Sprintf("%d of %d lines rejected, first: %s", rejected, rejected+len(entries), message))
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Elasticsearch _bulk API, used by Filebeat and Fluent Bit
+	bulkHandler := func(c *fiber.Ctx) error {
+		// Official clients check this header before trusting the response.
+		c.Set("X-Elastic-Product", "Elasticsearch")
+
+		payload, status, err := readPushPayload(c, cfg.Ingest.MaxPayloadBytes)
+		if err != nil {
+			return c.Status(status).JSON(fiber.Map{
+				"error":  bulkError{Type: "parse_exception", Reason: err.Error()},
+				"status": status,
+			})
+		}
+
+		// Every bulk item reports its own ID.
+		save := pushSaver(logRepo, true)
+
+		resp, err := ingestBulk(c.Context(), payload, c.Params("index"), cfg.Ingest.StreamChunkSize, save)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":  bulkError{Type: "illegal_argument_exception", Reason: err.Error()},
+				"status": fiber.StatusBadRequest,
+			})
+		}
+		if resp.Errors {
+			log.Printf("[warning] bulk request with %d items had failures", len(resp.Items))
+		}
+		return c.JSON(resp)
+	}
+	app.Post("/_bulk", bulkHandler)
+	app.Post("/:index/_bulk", bulkHandler)
+
+	// Elasticsearch info document. Filebeat and Fluent Bit query it on
+	// connect to check the version; Get also answers HEAD, which clients
+	// use as a ping.
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-Elastic-Product", "Elasticsearch")
+		return c.JSON(newBulkInfo())
+	})
+
+	// GELF HTTP input, one message per line (Graylog compatible)
+	if gelfBatcher != nil {
+		app.Post("/gelf", func(c *fiber.Ctx) error {
+			payload, status, err := readPushPayload(c, cfg.Gelf.MaxMessageBytes)
+			if err != nil {
+				return c.Status(status).SendString(err.Error())
+			}
+
+			// Some senders compress the body without a Content-Encoding.
+			data, err := gelf.Decompress(payload, cfg.Gelf.MaxMessageBytes)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+			}
+
+			var accepted, rejected int
+			var firstErr error
+			err = gelf.ParseStream(data, time.Now().UTC(), c.IP(), func(entry *model.LogEntry, err error) {
+				if err == nil {
+					err = gelfBatcher.Add(c.Context(), entry)
+				}
+				if err != nil {
+					rejected++
+					if firstErr == nil {
+						firstErr = err
+					}
+					return
+				}
+				accepted++
+			})
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+			}
+
+			if accepted == 0 && firstErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(firstErr.Error())
+			}
+			if rejected > 0 {
+				log.Printf("[warning] gelf: rejected %d of %d messages from %s: %v", rejected, accepted+rejected, c.IP(), firstErr)
+			}
+			// Entries are saved asynchronously by the batcher.
+			return c.SendStatus(fiber.StatusAccepted)
+		})
+	}
+
+	// Replace a log entry
+	app.Put("/logs/:id", limitBody(bodyLimit), func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil || id <= 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid id")
+		}
+
+		var input CreateLogEntryRequest
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid body")
+		}
+
+		if err := input.validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+
+		// A positive ID makes Save take the UPDATE path.
+		logEntry := input.toModel()
+		logEntry.ID = id
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := logRepo.Save(ctx, &logEntry); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "log entry not found",
+				})
+			}
+
+			log.Printf("replace log entry error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to update log entry",
+			})
+		}
+
+		return c.JSON(logEntry)
+	})
+
+	// Partially update a log entry (JSON merge patch, RFC 7386)
+	app.Patch("/logs/:id", limitBody(bodyLimit), func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil || id <= 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid id")
+		}
+
+		var patch map[string]any
+		if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid body")
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		// Always read the current state from the database, not the cache.
+		current, err := logRepo.GetByID(ctx, id, false, 0)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "log entry not found",
+				})
+			}
+
+			log.Printf("get log by id error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to get log entry",
+			})
+		}
+
+		input, err := applyMergePatch(requestFromModel(current), patch)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid body")
+		}
+
+		if err := input.validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+
+		logEntry := input.toModel()
+		logEntry.ID = id
+
+		if err := logRepo.Save(ctx, &logEntry); err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "log entry not found",
+				})
+			}
+
+			log.Printf("patch log entry error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to update log entry",
+			})
+		}
+
+		return c.JSON(logEntry)
+	})
+
+	// All log entries of a distributed trace, oldest first
+	app.Get("/traces/:trace_id/logs", func(c *fiber.Ctx) error {
+		traceID := model.NormalizeTraceID(c.Params("trace_id"))
+		if !model.ValidTraceID(traceID) {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid trace id")
+		}
+
+		limit := c.QueryInt("limit", 1000)
+		if limit <= 0 || limit > 1000 {
+			limit = 1000
+		}
+
+		params := repo.SearchParams{
+			TraceID: traceID,
+			Sort:    []repo.SortField{{Field: "timestamp"}, {Field: "id"}},
+			Limit:   limit,
+		}
+
+		if cursorStr := c.Query("cursor", ""); cursorStr != "" {
+			cur, err := repo.DecodeCursor(cursorStr)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   "invalid cursor",
+					"details": cursorStr,
+				})
+			}
+			params.After = &cur
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		entries, err := logRepo.Search(ctx, params)
+		if err != nil {
+			log.Printf("trace logs error: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to get trace logs",
+			})
+		}
+
+		resp := fiber.Map{
+			"trace_id": traceID,
+			"data":     entries,
+			"count":    len(entries),
+		}
+
+		if len(entries) == 0 {
+			resp["data"] = []model.LogEntry{}
+		}
+
+		if len(entries) == limit {
+			resp["next_cursor"] = repo.CursorFor(entries[len(entries)-1]).Encode()
+		}
+
+		return c.JSON(resp)
+	})
+
+	// ------------------------------------------------------------
+	// SERVE UNTIL SIGINT/SIGTERM
+	// ------------------------------------------------------------
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%s", cfg.App.Port))
+	}()
 	fmt.Printf("Server listening on port %s\n", cfg.App.Port)
-	log.Fatal(app.Listen(fmt.Sprintf(":%s", cfg.App.Port)))
+
+	select {
+	case err := <-listenErr:
+		log.Fatal(err)
+	case <-stopCtx.Done():
+	}
+
+	log.Printf("[info] shutting down")
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		log.Printf("[warning] http shutdown: %v", err)
+	}
+	stopJobs()
+	batchers.Wait()
 }
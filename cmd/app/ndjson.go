@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// maxReportedLineErrors caps the per-line errors kept in a summary so a
+// stream of garbage cannot grow the response without bound.
+const maxReportedLineErrors = 100
+
+// ndjsonLineError reports why a single line of an NDJSON stream was rejected.
+type ndjsonLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ndjsonSummary is the result of an NDJSON ingestion.
+type ndjsonSummary struct {
+	Lines           int               `json:"lines"`
+	Accepted        int64             `json:"accepted"`
+	Inserted        int64             `json:"inserted"` // below accepted when duplicates collapse
+	Rejected        int               `json:"rejected"`
+	Errors          []ndjsonLineError `json:"errors"`
+	ErrorsTruncated bool              `json:"errors_truncated,omitempty"`
+}
+
+func (s *ndjsonSummary) reject(line int, msg string) {
+	s.Rejected++
+	if len(s.Errors) < maxReportedLineErrors {
+		s.Errors = append(s.Errors, ndjsonLineError{Line: line, Error: msg})
+	} else {
+		s.ErrorsTruncated = true
+	}
+}
+
+// saveChunkFunc persists one chunk of validated entries.
+type saveChunkFunc func(ctx context.Context, entries []*model.LogEntry) (int64, error)
+
+// ingestNDJSON reads newline-delimited log entries from r and hands them to
+// save in chunks of chunkSize. Only one chunk is held in memory at a time.
+// Blank lines are skipped; lines longer than maxLine are rejected.
+// On a save error the summary reflects the chunks written so far.
+func ingestNDJSON(ctx context.Context, r io.Reader, chunkSize, maxLine int, save saveChunkFunc) (ndjsonSummary, error) {
+	summary := ndjsonSummary{Errors: []ndjsonLineError{}}
+	chunk := make([]*model.LogEntry, 0, chunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		n, err := save(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		summary.Accepted += int64(len(chunk))
+		summary.Inserted += n
+		chunk = chunk[:0]
+		return nil
+	}
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, tooLong, err := readLine(reader, maxLine)
+		if len(line) > 0 || tooLong || err == nil {
+			summary.Lines++
+			lineNo := summary.Lines
+
+			switch {
+			case tooLong:
+				summary.reject(lineNo, fmt.Sprintf("line exceeds %d bytes", maxLine))
+			case len(bytes.TrimSpace(line)) == 0:
+				// nothing to do for blank lines
+			default:
+				var input CreateLogEntryRequest
+				if err := json.Unmarshal(line, &input); err != nil {
+					summary.reject(lineNo, "invalid entry")
+				} else if err := input.validate(); err != nil {
+					summary.reject(lineNo, err.Error())
+				} else {
+					logEntry := input.toModel()
+					chunk = append(chunk, &logEntry)
+				}
+			}
+
+			if len(chunk) >= chunkSize {
+				if err := flush(); err != nil {
+					return summary, err
+				}
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return summary, fmt.Errorf("read body: %w", err)
+		}
+	}
+
+	if err := flush(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// readLine returns the next line without its terminator. If the line is
+// longer than maxLine the rest of it is discarded and tooLong is set.
+// err is io.EOF once the input is exhausted.
+func readLine(r *bufio.Reader, maxLine int) (line []byte, tooLong bool, err error) {
+	var buf []byte
+	for {
+		part, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return buf, tooLong, err
+		}
+		if !tooLong {
+			if len(buf)+len(part) > maxLine {
+				tooLong = true
+				buf = nil
+			} else {
+				buf = append(buf, part...)
+			}
+		}
+		if !isPrefix {
+			return buf, tooLong, nil
+		}
+	}
+}
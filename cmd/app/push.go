@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// pushChunkTimeout bounds the save of one chunk of a push request; the
+// request itself may take longer.
+const pushChunkTimeout = 30 * time.Second
+
+// readPushPayload reads the decoded body of a push request (OTLP, Loki,
+// bulk). On failure it returns the HTTP status to answer with.
+func readPushPayload(c *fiber.Ctx, limit int) ([]byte, int, error) {
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	payload, err := ingest.ReadPayload(body, c.Get(fiber.HeaderContentEncoding), limit)
+	switch {
+	case errors.Is(err, ingest.ErrPayloadTooLarge):
+		return nil, fiber.StatusRequestEntityTooLarge, err
+	case errors.Is(err, ingest.ErrUnsupportedEncoding):
+		return nil, fiber.StatusUnsupportedMediaType, err
+	case err != nil:
+		return nil, fiber.StatusBadRequest, err
+	}
+	return payload, fiber.StatusOK, nil
+}
+
+// limitBody buffers the request body for handlers that read it whole
+// (c.Body, BodyParser). StreamRequestBody hands bodies over the fiber
+// BodyLimit to the handler as a stream instead of rejecting them, so the
+// limit is enforced here.
+func limitBody(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stream := c.Context().RequestBodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid body")
+		}
+		if len(body) > limit {
+			// The rest of the body is left unread, so the connection
+			// cannot carry another request.
+			c.Context().SetConnectionClose()
+			return c.SendStatus(fiber.StatusRequestEntityTooLarge)
+		}
+		c.Request().SetBody(body)
+		return c.Next()
+	}
+}
+
+// pushSaver returns the saveChunkFunc of the push endpoints (OTLP, Loki,
+// bulk), which gives every chunk its own deadline.
+func pushSaver(logRepo *repo.Repo, returnIDs bool) saveChunkFunc {
+	return func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+		ctx, cancel := context.WithTimeout(ctx, pushChunkTimeout)
+		defer cancel()
+		return logRepo.SaveBatch(ctx, entries, returnIDs)
+	}
+}
+
+// pushSave saves the entries of a push request in chunks of chunkSize. On
+// failure it returns the status and text to answer with: 400 for data
+// errors, which a retry would hit again, and 503 otherwise, which agents
+// retry. Chunks saved before the failure stay committed, so a retried
+// request stores them a second time.
+func pushSave(ctx context.Context, logRepo *repo.Repo, entries []*model.LogEntry, chunkSize int) (int, string, error) {
+	if _, err := saveInChunks(ctx, entries, chunkSize, pushSaver(logRepo, false)); err != nil {
+		if msg, ok := dataError(err); ok {
+			return fiber.StatusBadRequest, "invalid log entries: " + msg, err
+		}
+		return fiber.StatusServiceUnavailable, "failed to save log entries", err
+	}
+	return fiber.StatusOK, "", nil
+}
+
+// saveInChunks hands entries to save in chunks of chunkSize and returns
+// the number written before the first error.
+func saveInChunks(ctx context.Context, entries []*model.LogEntry, chunkSize int, save saveChunkFunc) (int64, error) {
+	var saved int64
+	for len(entries) > 0 {
+		n := min(chunkSize, len(entries))
+		written, err := save(ctx, entries[:n])
+		saved += written
+		if err != nil {
+			return saved, err
+		}
+		entries = entries[n:]
+	}
+	return saved, nil
+}
+
+// dataError reports whether err is a Postgres data exception (SQLSTATE
+// class 22), e.g. a value the column type cannot hold, and returns its
+// message. Retrying the same payload cannot succeed, so clients get a 4xx
+// instead of a 5xx.
+func dataError(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") {
+		return pgErr.Message, true
+	}
+	return "", false
+}
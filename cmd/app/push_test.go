@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLimitBody(t *testing.T) {
+	app := fiber.New(fiber.Config{BodyLimit: 16, StreamRequestBody: true})
+	app.Post("/", limitBody(16), func(c *fiber.Ctx) error {
+		return c.Send(c.Body())
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within limit", body: "0123456789abcdef", wantStatus: fiber.StatusOK},
+		{name: "over limit", body: strings.Repeat("x", 64), wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "over limit without length", body: strings.Repeat("x", 64), chunked: true, wantStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "small without length", body: "small", chunked: true, wantStatus: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == fiber.StatusOK {
+				got, _ := io.ReadAll(resp.Body)
+				if string(got) != tt.body {
+					t.Errorf("body = %q, want %q", got, tt.body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// CreateLogEntryRequest represents the request body for creating a log entry
+type CreateLogEntryRequest struct {
+	Level      string         `json:"level"`
+	Message    string         `json:"message"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Attributes map[string]any `json:"attributes"`
+
+	Service     string `json:"service"`
+	Host        string `json:"host"`
+	Environment string `json:"environment"`
+	Version     string `json:"version"`
+
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+}
+
+// batchItemError reports why a single item of a batch was rejected.
+type batchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// validate checks the required fields of a create or update request.
+func (r CreateLogEntryRequest) validate() error {
+	if r.Level == "" {
+		return errors.New("level is required")
+	}
+	if _, err := model.ParseSeverity(r.Level); err != nil {
+		return fmt.Errorf("invalid level %q (valid: trace, debug, info, warn, error, fatal)", r.Level)
+	}
+	if r.Message == "" {
+		return errors.New("message is required")
+	}
+	if id := model.NormalizeTraceID(r.TraceID); id != "" && !model.ValidTraceID(id) {
+		return fmt.Errorf("invalid trace_id %q, expected 32 hex characters", r.TraceID)
+	}
+	if id := model.NormalizeTraceID(r.SpanID); id != "" && !model.ValidSpanID(id) {
+		return fmt.Errorf("invalid span_id %q, expected 16 hex characters", r.SpanID)
+	}
+	if r.SpanID != "" && r.TraceID == "" {
+		return errors.New("span_id requires trace_id")
+	}
+	return nil
+}
+
+// applyTraceparent fills missing trace and span IDs from a W3C traceparent
+// header value. Invalid headers are ignored, as the spec requires.
+func (r *CreateLogEntryRequest) applyTraceparent(header string) {
+	if r.TraceID != "" {
+		return
+	}
+	traceID, spanID, ok := parseTraceparent(header)
+	if !ok {
+		return
+	}
+	r.TraceID = traceID
+	if r.SpanID == "" {
+		r.SpanID = spanID
+	}
+}
+
+// toModel converts a validated request into a LogEntry without an ID.
+// The level is normalized to its canonical severity and a missing
+// timestamp defaults to the current time.
+func (r CreateLogEntryRequest) toModel() model.LogEntry {
+	entry := model.LogEntry{
+		Level:      r.Level,
+		Message:    r.Message,
+		Timestamp:  r.Timestamp,
+		Attributes: r.Attributes,
+
+		Service:     strings.TrimSpace(r.Service),
+		Host:        strings.TrimSpace(r.Host),
+		Environment: strings.TrimSpace(r.Environment),
+		Version:     strings.TrimSpace(r.Version),
+
+		TraceID: model.NormalizeTraceID(r.TraceID),
+		SpanID:  model.NormalizeTraceID(r.SpanID),
+	}
+
+	// Cannot fail after validate, which checks the same level.
+	_ = entry.Normalize()
+
+	// Set timestamp if not provided by client
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	return entry
+}
+
+// parseTraceparent extracts the trace and parent span ID from a W3C
+// traceparent header ("00-<trace-id>-<parent-id>-<flags>"). Versions other
+// than 00 may carry extra fields after the flags, which are ignored.
+func parseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || !isLowerHex(version) {
+		return "", "", false
+	}
+	if version == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	if len(flags) != 2 || !isLowerHex(flags) {
+		return "", "", false
+	}
+	if !model.ValidTraceID(traceID) || !model.ValidSpanID(spanID) {
+		return "", "", false
+	}
+
+	return traceID, spanID, true
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFromModel builds the writable view of an existing entry.
+func requestFromModel(entry *model.LogEntry) CreateLogEntryRequest {
+	return CreateLogEntryRequest{
+		Level:      entry.Level,
+		Message:    entry.Message,
+		Timestamp:  entry.Timestamp,
+		Attributes: entry.Attributes,
+
+		Service:     entry.Service,
+		Host:        entry.Host,
+		Environment: entry.Environment,
+		Version:     entry.Version,
+
+		TraceID: entry.TraceID,
+		SpanID:  entry.SpanID,
+	}
+}
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to the request.
+// Keys set to null are removed, objects are merged recursively and any
+// other value replaces the current one.
+func applyMergePatch(current CreateLogEntryRequest, patch map[string]any) (CreateLogEntryRequest, error) {
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return CreateLogEntryRequest{}, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return CreateLogEntryRequest{}, err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return CreateLogEntryRequest{}, err
+	}
+
+	var result CreateLogEntryRequest
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return CreateLogEntryRequest{}, err
+	}
+	return result, nil
+}
+
+// mergePatch implements the MergePatch algorithm from RFC 7386 section 2.
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
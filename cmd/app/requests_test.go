@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	current := CreateLogEntryRequest{
+		Level:      "info",
+		Message:    "started",
+		Timestamp:  time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+		Attributes: map[string]any{"user": "42", "http": map[string]any{"method": "GET", "status": 200.0}},
+		Service:    "api",
+		Host:       "web-1",
+	}
+
+	tests := []struct {
+		name  string
+		patch map[string]any
+		want  func(r *CreateLogEntryRequest)
+	}{
+		{
+			name:  "replace scalar",
+			patch: map[string]any{"level": "error"},
+			want:  func(r *CreateLogEntryRequest) { r.Level = "error" },
+		},
+		{
+			name:  "null removes",
+			patch: map[string]any{"host": nil},
+			want:  func(r *CreateLogEntryRequest) { r.Host = "" },
+		},
+		{
+			name:  "objects merge recursively",
+			patch: map[string]any{"attributes": map[string]any{"user": nil, "http": map[string]any{"status": 500.0}, "retry": true}},
+			want: func(r *CreateLogEntryRequest) {
+				r.Attributes = map[string]any{"http": map[string]any{"method": "GET", "status": 500.0}, "retry": true}
+			},
+		},
+		{
+			name:  "non-object replaces object",
+			patch: map[string]any{"attributes": map[string]any{"http": "gone"}},
+			want: func(r *CreateLogEntryRequest) {
+				r.Attributes = map[string]any{"user": "42", "http": "gone"}
+			},
+		},
+		{
+			name:  "empty patch",
+			patch: map[string]any{},
+			want:  func(r *CreateLogEntryRequest) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyMergePatch(current, tt.patch)
+			if err != nil {
+				t.Fatalf("applyMergePatch() error = %v", err)
+			}
+			want := current
+			want.Attributes = map[string]any{"user": "42", "http": map[string]any{"method": "GET", "status": 200.0}}
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("applyMergePatch() = %+v\nwant %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := applyMergePatch(current, map[string]any{"timestamp": "yesterday"}); err == nil {
+		t.Error("applyMergePatch() accepted an invalid timestamp")
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		traceID string
+		spanID  string
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", "", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		traceID, spanID, ok := parseTraceparent(tt.header)
+		if traceID != tt.traceID || spanID != tt.spanID || ok != tt.ok {
+			t.Errorf("parseTraceparent(%q) = %q, %q, %t", tt.header, traceID, spanID, ok)
+		}
+	}
+}
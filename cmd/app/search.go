@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// totalMode selects how /logs/search computes the total match count.
+type totalMode int
+
+const (
+	totalNone totalMode = iota
+	totalExact
+	totalEstimate
+)
+
+// queryError describes an invalid query parameter. It renders as the
+// {"error", "details"} body used by the search endpoints.
+type queryError struct {
+	Message string
+	Details string
+}
+
+func (e *queryError) Error() string {
+	return e.Message
+}
+
+// body returns the JSON body for a 400 response.
+func (e *queryError) body() fiber.Map {
+	if e.Details == "" {
+		return fiber.Map{"error": e.Message}
+	}
+	return fiber.Map{"error": e.Message, "details": e.Details}
+}
+
+// parseSearchFilters reads the filter query parameters shared by
+// /logs/search and /logs/export. Limit and Offset are left to the caller.
+// The params hold copies of the query strings and may outlive the request.
+func parseSearchFilters(c *fiber.Ctx) (repo.SearchParams, *queryError) {
+	params := repo.SearchParams{
+		Levels:          normalizeLevels(splitQueryList(c, "level")),
+		ExcludeLevels:   normalizeLevels(splitQueryList(c, "-level")),
+		MessageContains: queryValues(c, "message_contains"),
+		ExcludeMessages: queryValues(c, "-message_contains"),
+		Services:        splitQueryList(c, "service"),
+		Hosts:           splitQueryList(c, "host"),
+		Environments:    splitQueryList(c, "environment"),
+		Versions:        splitQueryList(c, "version"),
+		Fingerprints:    splitQueryList(c, "fingerprint"),
+	}
+
+	switch match := strings.ToLower(queryString(c, "message_match")); match {
+	case "", "all", "and":
+		params.MessageMatch = repo.MatchAll
+	case "any", "or":
+		params.MessageMatch = repo.MatchAny
+	default:
+		return repo.SearchParams{}, &queryError{Message: "invalid message_match, expected all or any", Details: match}
+	}
+
+	if pattern := queryString(c, "message_regex"); pattern != "" {
+		if err := repo.ValidateRegex(pattern); err != nil {
+			return repo.SearchParams{}, &queryError{Message: "invalid message_regex", Details: err.Error()}
+		}
+		params.MessageRegex = pattern
+	}
+
+	params.Query = strings.TrimSpace(queryString(c, "q"))
+	params.Highlight = c.QueryBool("highlight", false)
+
+	if traceID := queryString(c, "trace_id"); traceID != "" {
+		params.TraceID = model.NormalizeTraceID(traceID)
+		if !model.ValidTraceID(params.TraceID) {
+			return repo.SearchParams{}, &queryError{Message: "invalid trace_id", Details: traceID}
+		}
+	}
+
+	if spanID := queryString(c, "span_id"); spanID != "" {
+		params.SpanID = model.NormalizeTraceID(spanID)
+		if !model.ValidSpanID(params.SpanID) {
+			return repo.SearchParams{}, &queryError{Message: "invalid span_id", Details: spanID}
+		}
+	}
+
+	for i, fp := range params.Fingerprints {
+		params.Fingerprints[i] = strings.ToLower(fp)
+		if len(fp) != model.FingerprintLength || !isLowerHex(params.Fingerprints[i]) {
+			return repo.SearchParams{}, &queryError{Message: "invalid fingerprint", Details: fp}
+		}
+	}
+
+	attrs, qerr := parseAttributeFilters(c)
+	if qerr != nil {
+		return repo.SearchParams{}, qerr
+	}
+	params.Attributes = attrs
+
+	if minLevel := queryString(c, "min_level"); minLevel != "" {
+		sev, err := model.ParseSeverity(minLevel)
+		if err != nil {
+			return repo.SearchParams{}, &queryError{Message: "invalid min_level", Details: minLevel}
+		}
+		params.MinSeverity = sev
+	}
+
+	if sinceStr := queryString(c, "since"); sinceStr != "" {
+		t, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return repo.SearchParams{}, &queryError{Message: "invalid since timestamp format", Details: sinceStr}
+		}
+		params.Since = &t
+	}
+
+	if untilStr := queryString(c, "until"); untilStr != "" {
+		t, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			return repo.SearchParams{}, &queryError{Message: "invalid until timestamp format", Details: untilStr}
+		}
+		params.Until = &t
+	}
+
+	if sortStr := queryString(c, "sort"); sortStr != "" {
+		fields, err := repo.ParseSort(sortStr)
+		if err != nil {
+			return repo.SearchParams{}, &queryError{Message: err.Error(), Details: sortStr}
+		}
+		params.Sort = fields
+
+		if repo.SortsByRelevance(fields) && params.Query == "" {
+			return repo.SearchParams{}, &queryError{Message: repo.ErrRelevanceSort.Error(), Details: sortStr}
+		}
+	}
+
+	if cursorStr := queryString(c, "cursor"); cursorStr != "" {
+		cur, err := repo.DecodeCursor(cursorStr)
+		if err != nil {
+			return repo.SearchParams{}, &queryError{Message: "invalid cursor", Details: cursorStr}
+		}
+		params.After = &cur
+
+		if !repo.CursorCompatible(params.Sort) {
+			return repo.SearchParams{}, &queryError{Message: repo.ErrCursorSort.Error(), Details: queryString(c, "sort")}
+		}
+	}
+
+	return params, nil
+}
+
+// searchFailed answers a failed search query. Regexes that Postgres
+// rejects despite ValidateRegex are the client's fault.
+func searchFailed(c *fiber.Ctx, logPrefix string, err error) error {
+	if errors.Is(err, repo.ErrInvalidRegex) {
+		log.Printf("%s: %v", logPrefix, err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid message_regex",
+		})
+	}
+
+	log.Printf("%s: %v", logPrefix, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "search failed",
+	})
+}
+
+// parseSearchExtras reads the opt-in with_total and facets parameters of
+// /logs/search. with_total accepts true/exact or estimate; facets is a
+// comma-separated list of facet fields known to the repository.
+func parseSearchExtras(c *fiber.Ctx) (totalMode, []string, *queryError) {
+	mode := totalNone
+	switch raw := strings.ToLower(c.Query("with_total", "")); raw {
+	case "", "false", "0":
+	case "true", "1", "exact":
+		mode = totalExact
+	case "estimate", "estimated":
+		mode = totalEstimate
+	default:
+		return totalNone, nil, &queryError{Message: "invalid with_total value", Details: raw}
+	}
+
+	var fields []string
+	for _, field := range strings.Split(c.Query("facets", ""), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !repo.ValidFacet(field) {
+			return totalNone, nil, &queryError{Message: "unknown facet field", Details: field}
+		}
+		fields = append(fields, field)
+	}
+
+	return mode, fields, nil
+}
+
+// queryString returns a copy of a query parameter. c.Query aliases the
+// request buffer, which is reused once the handler returns, while search
+// params outlive it in the /logs/export stream writer.
+func queryString(c *fiber.Ctx, key string) string {
+	return strings.Clone(c.Query(key))
+}
+
+// queryValues returns every non-empty value of a repeatable query parameter,
+// e.g. message_contains=a&message_contains=b.
+func queryValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(key) {
+		if len(raw) > 0 {
+			values = append(values, string(raw))
+		}
+	}
+	return values
+}
+
+// splitQueryList is like queryValues but also splits each value on commas,
+// so level=error,warn and level=error&level=warn are equivalent.
+func splitQueryList(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range queryValues(c, key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// normalizeLevels maps level names to their canonical spelling, since stored
+// levels are canonical ("ERR" matches "error"). Unknown names are kept as-is
+// to still find entries written before normalization.
+func normalizeLevels(levels []string) []string {
+	for i, level := range levels {
+		if sev, err := model.ParseSeverity(level); err == nil {
+			levels[i] = sev.String()
+		}
+	}
+	return levels
+}
+
+// parseAttributeFilters collects attr.* filters such as attr.user_id=42 or
+// attr.http.status>=500. The query string splits the latter into the key
+// "attr.http.status>" and the value "500", and attr.x>5 into the key
+// "attr.x>5" with no value, so the expression is rebuilt before parsing.
+func parseAttributeFilters(c *fiber.Ctx) ([]repo.AttributeFilter, *queryError) {
+	var filters []repo.AttributeFilter
+	var qerr *queryError
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		k := string(key)
+		if qerr != nil || !strings.HasPrefix(k, "attr.") {
+			return
+		}
+
+		expr := strings.TrimPrefix(k, "attr.")
+		if len(value) > 0 || !strings.ContainsAny(expr, "<>") {
+			expr += "=" + string(value)
+		}
+
+		f, err := repo.ParseAttributeFilter(expr)
+		if err != nil {
+			qerr = &queryError{Message: "invalid attribute filter", Details: k + "=" + string(value)}
+			return
+		}
+		filters = append(filters, f)
+	})
+
+	return filters, qerr
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// parseQuery runs parseSearchFilters and parseSearchExtras on a request
+// with the given raw query string.
+func parseQuery(t *testing.T, query string) (repo.SearchParams, totalMode, []string, *queryError) {
+	t.Helper()
+
+	var (
+		params repo.SearchParams
+		mode   totalMode
+		facets []string
+		qerr   *queryError
+	)
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		params, qerr = parseSearchFilters(c)
+		if qerr == nil {
+			mode, facets, qerr = parseSearchExtras(c)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+query, nil)); err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+	return params, mode, facets, qerr
+}
+
+func TestParseSearchFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, p repo.SearchParams)
+	}{
+		{
+			name:  "comma and repeated lists",
+			query: "level=ERR,warn&level=info&service=api,%20worker&-level=debug",
+			check: func(t *testing.T, p repo.SearchParams) {
+				if want := []string{"error", "warn", "info"}; !reflect.DeepEqual(p.Levels, want) {
+					t.Errorf("Levels = %v, want %v", p.Levels, want)
+				}
+				if want := []string{"debug"}; !reflect.DeepEqual(p.ExcludeLevels, want) {
+					t.Errorf("ExcludeLevels = %v, want %v", p.ExcludeLevels, want)
+				}
+				if want := []string{"api", "worker"}; !reflect.DeepEqual(p.Services, want) {
+					t.Errorf("Services = %v, want %v", p.Services, want)
+				}
+			},
+		},
+		{
+			name:  "message terms are not split",
+			query: "message_contains=a,b&message_contains=&message_contains=c&message_match=any",
+			check: func(t *testing.T, p repo.SearchParams) {
+				if want := []string{"a,b", "c"}; !reflect.DeepEqual(p.MessageContains, want) {
+					t.Errorf("MessageContains = %v, want %v", p.MessageContains, want)
+				}
+				if p.MessageMatch != repo.MatchAny {
+					t.Errorf("MessageMatch = %v, want MatchAny", p.MessageMatch)
+				}
+			},
+		},
+		{
+			name:  "attribute filters",
+			query: "attr.user_id=42&attr.http.status>=500&attr.latency>5&other=1",
+			check: func(t *testing.T, p repo.SearchParams) {
+				want := []repo.AttributeFilter{
+					{Path: []string{"user_id"}, Op: repo.AttrEq, Value: "42"},
+					{Path: []string{"http", "status"}, Op: repo.AttrGte, Value: "500"},
+					{Path: []string{"latency"}, Op: repo.AttrGt, Value: "5"},
+				}
+				if !reflect.DeepEqual(p.Attributes, want) {
+					t.Errorf("Attributes = %+v, want %+v", p.Attributes, want)
+				}
+			},
+		},
+		{
+			name:  "time range",
+			query: "since=2024-05-01T00:00:00Z&until=2024-05-02T00:00:00Z",
+			check: func(t *testing.T, p repo.SearchParams) {
+				if p.Since == nil || p.Until == nil || !p.Until.After(*p.Since) {
+					t.Errorf("Since = %v, Until = %v", p.Since, p.Until)
+				}
+			},
+		},
+		{
+			name:  "trace id is normalized",
+			query: "trace_id=4BF92F3577B34DA6A3CE929D0E0E4736",
+			check: func(t *testing.T, p repo.SearchParams) {
+				if p.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+					t.Errorf("TraceID = %q", p.TraceID)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _, _, qerr := parseQuery(t, tt.query)
+			if qerr != nil {
+				t.Fatalf("parseSearchFilters() error = %v (%s)", qerr, qerr.Details)
+			}
+			tt.check(t, params)
+		})
+	}
+}
+
+func TestParseSearchFiltersInvalid(t *testing.T) {
+	tests := []struct {
+		query   string
+		message string
+	}{
+		{"message_match=some", "invalid message_match, expected all or any"},
+		{"message_regex=%5Cbword", "invalid message_regex"},
+		{"trace_id=xyz", "invalid trace_id"},
+		{"span_id=0000000000000000", "invalid span_id"},
+		{"fingerprint=abc", "invalid fingerprint"},
+		{"attr.=1", "invalid attribute filter"},
+		{"min_level=loud", "invalid min_level"},
+		{"since=yesterday", "invalid since timestamp format"},
+		{"until=2024-05-01", "invalid until timestamp format"},
+		{"sort=relevance", repo.ErrRelevanceSort.Error()},
+		{"sort=size", `invalid sort: unknown field "size"`},
+		{"cursor=garbage", "invalid cursor"},
+		{"with_total=maybe", "invalid with_total value"},
+		{"facets=level,message", "unknown facet field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, _, _, qerr := parseQuery(t, tt.query)
+			if qerr == nil {
+				t.Fatalf("parseQuery(%q) succeeded", tt.query)
+			}
+			if qerr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", qerr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseSearchExtras(t *testing.T) {
+	tests := []struct {
+		query  string
+		mode   totalMode
+		facets []string
+	}{
+		{"", totalNone, nil},
+		{"with_total=false", totalNone, nil},
+		{"with_total=true", totalExact, nil},
+		{"with_total=Estimate&facets=level,%20service,", totalEstimate, []string{"level", "service"}},
+	}
+
+	for _, tt := range tests {
+		_, mode, facets, qerr := parseQuery(t, tt.query)
+		if qerr != nil {
+			t.Fatalf("parseQuery(%q) error = %v", tt.query, qerr)
+		}
+		if mode != tt.mode || !reflect.DeepEqual(facets, tt.facets) {
+			t.Errorf("parseQuery(%q) = %v, %v, want %v, %v", tt.query, mode, facets, tt.mode, tt.facets)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	archivecfg "github.com/julian-richter/ApiTemplate/internal/config/archive"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// Archiver moves old log entries from Postgres to compressed NDJSON files,
+// one per UTC day, and restores them on demand.
+type Archiver struct {
+	repo        *repo.Repo
+	dir         string
+	compression archivecfg.Compression
+	after       time.Duration
+	restoreHold time.Duration
+	interval    time.Duration
+	batchSize   int
+}
+
+// NewArchiver creates an archiver from cfg.
+func NewArchiver(logRepo *repo.Repo, cfg config.Config) *Archiver {
+	return &Archiver{
+		repo:        logRepo,
+		dir:         cfg.Archive.Dir,
+		compression: cfg.Archive.Compression,
+		after:       cfg.Archive.After,
+		restoreHold: cfg.Archive.RestoreHold,
+		interval:    cfg.Archive.Interval,
+		batchSize:   cfg.Archive.BatchSize,
+	}
+}
+
+// Run archives entries older than the configured age immediately and then
+// every interval until ctx is cancelled. Each pass also deletes restored
+// entries whose restore hold has expired.
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if _, err := a.ArchiveBefore(ctx, now.Add(-a.after)); err != nil && ctx.Err() == nil {
+			log.Printf("[warning] archive pass failed: %v", err)
+		}
+		if _, err := a.ExpireRestored(ctx, now.Add(-a.restoreHold)); err != nil && ctx.Err() == nil {
+			log.Printf("[warning] expiring restored entries failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArchiveBefore archives and deletes every complete UTC day before cutoff,
+// oldest first. It returns the manifests of the written files. Restored
+// entries are skipped, the archive already holds them.
+func (a *Archiver) ArchiveBefore(ctx context.Context, cutoff time.Time) ([]*Manifest, error) {
+	if err := a.finishPending(ctx); err != nil {
+		return nil, err
+	}
+
+	var written []*Manifest
+	for {
+		oldest, ok, err := a.repo.OldestBefore(ctx, cutoff)
+		if err != nil || !ok {
+			return written, err
+		}
+
+		day := oldest.UTC().Truncate(24 * time.Hour)
+		if day.AddDate(0, 0, 1).After(cutoff) {
+			return written, nil
+		}
+
+		m, err := a.archiveDay(ctx, day)
+		if err != nil {
+			return written, fmt.Errorf("archive %s: %w", day.Format(dayLayout), err)
+		}
+		if m == nil {
+			// Nothing was exported although an entry exists, e.g. it was
+			// deleted concurrently. Stop instead of spinning.
+			return written, nil
+		}
+
+		log.Printf("[info] archived %d entries of %s to %s", m.Entries, m.Day, m.File)
+		written = append(written, m)
+	}
+}
+
+// ExpireRestored deletes the entries restored before t without archiving
+// them again, since their archive files are still in place. It returns the
+// number of deleted entries.
+func (a *Archiver) ExpireRestored(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	for {
+		ids, err := a.repo.PurgeRestored(ctx, t, a.batchSize)
+		deleted += int64(len(ids))
+		if err != nil || len(ids) < a.batchSize {
+			if deleted > 0 {
+				log.Printf("[info] deleted %d restored entries after their hold expired", deleted)
+			}
+			return deleted, err
+		}
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+	}
+}
+
+// archiveDay writes the entries of one UTC day to a new archive file and
+// deletes them from Postgres once file and manifest are on disk. The
+// manifest stays pending until the entries are deleted, so a pass that is
+// interrupted in between is completed by finishPending instead of
+// archiving the entries a second time. It returns nil if the day has no
+// entries.
+func (a *Archiver) archiveDay(ctx context.Context, day time.Time) (*Manifest, error) {
+	base, err := nextBasePath(a.dir, day)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(base), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	defer tmp.Close()
+
+	m := &Manifest{
+		Day:         day.Format(dayLayout),
+		File:        filepath.Base(base) + extension(a.compression),
+		Compression: a.compression,
+	}
+
+	h := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tmp, h)}
+	zw, err := compressor(a.compression, counter)
+	if err != nil {
+		return nil, err
+	}
+	// Closing twice is a no-op; this releases the encoder on early returns.
+	defer zw.Close()
+	bw := bufio.NewWriter(zw)
+	enc := json.NewEncoder(bw)
+
+	// Entries are deleted by ID so rows arriving during the export are
+	// left for the next pass instead of being lost.
+	var ids []int
+	until := day.AddDate(0, 0, 1).Add(-time.Microsecond)
+	params := repo.SearchParams{
+		Since:           &day,
+		Until:           &until,
+		Sort:            []repo.SortField{{Field: "timestamp"}, {Field: "id"}},
+		ExcludeRestored: true,
+	}
+	err = a.repo.Export(ctx, params, func(e *model.LogEntry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			m.MinID, m.From = e.ID, e.Timestamp
+		}
+		m.MinID = min(m.MinID, e.ID)
+		m.MaxID = max(m.MaxID, e.ID)
+		m.To = e.Timestamp
+		ids = append(ids, e.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(filepath.Dir(base), m.File)); err != nil {
+		return nil, err
+	}
+
+	m.Entries = len(ids)
+	m.Bytes = counter.n
+	m.SHA256 = hex.EncodeToString(h.Sum(nil))
+	m.CreatedAt = time.Now().UTC()
+	m.path = base + manifestSuffix
+	if err := writeManifest(m.path+pendingSuffix, m); err != nil {
+		return nil, err
+	}
+
+	if err := a.deleteIDs(ctx, ids); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(m.path+pendingSuffix, m.path); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// finishPending completes archive passes that were interrupted after the
+// manifest was written: it deletes the entries of each pending data file,
+// which may be gone already, and then makes its manifest final.
+func (a *Archiver) finishPending(ctx context.Context) error {
+	paths, err := filepath.Glob(filepath.Join(a.dir, "*", "log_entries-*"+manifestSuffix+pendingSuffix))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		m, err := readManifest(path)
+		if err != nil {
+			return err
+		}
+		if err := m.Verify(); err != nil {
+			return fmt.Errorf("pending archive: %w", err)
+		}
+		ids, err := archivedIDs(m)
+		if err != nil {
+			return fmt.Errorf("pending archive %s: %w", m.File, err)
+		}
+		if err := a.deleteIDs(ctx, ids); err != nil {
+			return err
+		}
+		if err := os.Rename(path, strings.TrimSuffix(path, pendingSuffix)); err != nil {
+			return err
+		}
+		log.Printf("[info] completed interrupted archive of %s (%s)", m.Day, m.File)
+	}
+	return nil
+}
+
+// deleteIDs deletes archived entries in batches.
+func (a *Archiver) deleteIDs(ctx context.Context, ids []int) error {
+	for start := 0; start < len(ids); start += a.batchSize {
+		end := min(start+a.batchSize, len(ids))
+		if _, err := a.repo.DeleteIDs(ctx, ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivedIDs returns the IDs of the entries in the data file of m.
+func archivedIDs(m *Manifest) ([]int, error) {
+	f, err := os.Open(m.dataPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := decompressor(m.Compression, f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), maxRestoreLine)
+
+	ids := make([]int, 0, m.Entries)
+	for scanner.Scan() {
+		var e struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(ids)+1, err)
+		}
+		ids = append(ids, e.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) != m.Entries {
+		return nil, fmt.Errorf("file has %d entries, manifest says %d", len(ids), m.Entries)
+	}
+	return ids, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,211 @@
+package archive
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	archivecfg "github.com/julian-richter/ApiTemplate/internal/config/archive"
+)
+
+// dayLayout is the format of Manifest.Day and of the date in file names.
+const dayLayout = "2006-01-02"
+
+// manifestSuffix marks the manifest next to each data file. A data file
+// without a manifest is incomplete and ignored by Restore.
+const manifestSuffix = ".manifest.json"
+
+// pendingSuffix marks a manifest whose entries may not all be deleted from
+// Postgres yet. It is ignored by Restore and completed by the next pass.
+const pendingSuffix = ".pending"
+
+// Manifest describes one archive file.
+type Manifest struct {
+	Day         string                 `json:"day"`  // UTC day of the entries, YYYY-MM-DD
+	File        string                 `json:"file"` // data file name, relative to the manifest
+	Compression archivecfg.Compression `json:"compression"`
+	Entries     int                    `json:"entries"`
+	MinID       int                    `json:"min_id"`
+	MaxID       int                    `json:"max_id"`
+	From        time.Time              `json:"from"` // oldest entry timestamp
+	To          time.Time              `json:"to"`   // newest entry timestamp
+	Bytes       int64                  `json:"bytes"`
+	SHA256      string                 `json:"sha256"` // of the compressed data file
+	CreatedAt   time.Time              `json:"created_at"`
+
+	path string // manifest location, set when read
+}
+
+// dataPath returns the location of the data file described by m.
+func (m *Manifest) dataPath() string {
+	return filepath.Join(filepath.Dir(m.path), m.File)
+}
+
+// extension returns the data file extension for a codec.
+func extension(c archivecfg.Compression) string {
+	if c == archivecfg.CompressionGzip {
+		return ".ndjson.gz"
+	}
+	return ".ndjson.zst"
+}
+
+// compressor wraps w with the encoder of codec c.
+func compressor(c archivecfg.Compression, w io.Writer) (io.WriteCloser, error) {
+	if c == archivecfg.CompressionGzip {
+		return gzip.NewWriter(w), nil
+	}
+	return zstd.NewWriter(w)
+}
+
+// decompressor wraps r with the decoder of codec c.
+func decompressor(c archivecfg.Compression, r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case archivecfg.CompressionGzip:
+		return gzip.NewReader(r)
+	case archivecfg.CompressionZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", c)
+	}
+}
+
+// basePath returns the path prefix of part n of day, e.g.
+// dir/2026/log_entries-2026-01-17 or dir/2026/log_entries-2026-01-17.2.
+func basePath(dir string, day time.Time, part int) string {
+	name := "log_entries-" + day.Format(dayLayout)
+	if part > 1 {
+		name += fmt.Sprintf(".%d", part)
+	}
+	return filepath.Join(dir, day.Format("2006"), name)
+}
+
+// nextBasePath returns the first part of day that has no manifest yet, so
+// re-archiving a day (e.g. late arrivals) never overwrites earlier files.
+func nextBasePath(dir string, day time.Time) (string, error) {
+	for part := 1; ; part++ {
+		base := basePath(dir, day, part)
+		_, err := os.Stat(base + manifestSuffix)
+		if os.IsNotExist(err) {
+			return base, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// writeManifest stores m atomically at path.
+func writeManifest(path string, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(b, '\n'))
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadManifests returns the manifests in dir whose day lies within
+// [from, to], ordered by day and part.
+func ReadManifests(dir string, from, to time.Time) ([]*Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "log_entries-*"+manifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := from.UTC().Format(dayLayout), to.UTC().Format(dayLayout)
+
+	var manifests []*Manifest
+	for _, path := range paths {
+		m, err := readManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		if m.Day < first || m.Day > last {
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		if manifests[i].Day != manifests[j].Day {
+			return manifests[i].Day < manifests[j].Day
+		}
+		return manifests[i].CreatedAt.Before(manifests[j].CreatedAt)
+	})
+
+	return manifests, nil
+}
+
+// readManifest reads the manifest at path.
+func readManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	if strings.ContainsAny(m.File, `/\`) {
+		return nil, fmt.Errorf("manifest %s: invalid file name %q", path, m.File)
+	}
+	m.path = path
+	return &m, nil
+}
+
+// Verify checks the size and checksum of the data file described by m.
+func (m *Manifest) Verify() error {
+	f, err := os.Open(m.dataPath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if n != m.Bytes {
+		return fmt.Errorf("%s: size %d, manifest says %d", m.File, n, m.Bytes)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != m.SHA256 {
+		return fmt.Errorf("%s: checksum mismatch", m.File)
+	}
+	return nil
+}
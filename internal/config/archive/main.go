@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawEnabled := strings.TrimSpace(env.GetEnv("ARCHIVE_ENABLED", "false"))
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_ENABLED value %q: %w", rawEnabled, err)
+	}
+
+	dir := strings.TrimSpace(env.GetEnv("ARCHIVE_DIR", "./archive"))
+	if dir == "" {
+		return Config{}, fmt.Errorf("ARCHIVE_DIR must not be empty")
+	}
+
+	compression := Compression(strings.ToLower(strings.TrimSpace(env.GetEnv("ARCHIVE_COMPRESSION", "zstd"))))
+	if !compression.Valid() {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_COMPRESSION value %q, expected zstd or gzip", compression)
+	}
+
+	after, err := env.ParseAge(strings.TrimSpace(env.GetEnv("ARCHIVE_AFTER", "90d")))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_AFTER: %w", err)
+	}
+
+	restoreHold, err := env.ParseAge(strings.TrimSpace(env.GetEnv("ARCHIVE_RESTORE_HOLD", "30d")))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_RESTORE_HOLD: %w", err)
+	}
+
+	rawInterval := strings.TrimSpace(env.GetEnv("ARCHIVE_INTERVAL", "24h"))
+	interval, err := time.ParseDuration(rawInterval)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_INTERVAL value %q: %w", rawInterval, err)
+	}
+
+	if interval <= 0 {
+		return Config{}, fmt.Errorf("ARCHIVE_INTERVAL must be positive, got %s", interval)
+	}
+
+	rawBatch := strings.TrimSpace(env.GetEnv("ARCHIVE_BATCH_SIZE", "1000"))
+	batchSize, err := strconv.Atoi(rawBatch)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ARCHIVE_BATCH_SIZE value %q: %w", rawBatch, err)
+	}
+
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("ARCHIVE_BATCH_SIZE must be positive, got %d", batchSize)
+	}
+
+	return Config{
+		Enabled:     enabled,
+		Dir:         dir,
+		Compression: compression,
+		After:       after,
+		RestoreHold: restoreHold,
+		Interval:    interval,
+		BatchSize:   batchSize,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package archive
+
+import "time"
+
+// Compression is the codec used for archive files.
+type Compression string
+
+const (
+	CompressionZstd Compression = "zstd"
+	CompressionGzip Compression = "gzip"
+)
+
+// Valid reports whether c is a supported codec.
+func (c Compression) Valid() bool {
+	return c == CompressionZstd || c == CompressionGzip
+}
+
+type Config struct {
+	Enabled     bool
+	Dir         string
+	Compression Compression
+	After       time.Duration
+	RestoreHold time.Duration // how long restored entries are kept from archiving and retention
+	Interval    time.Duration
+	BatchSize   int
+}
@@ -0,0 +1,82 @@
+package gelf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawEnabled := strings.TrimSpace(env.GetEnv("GELF_ENABLED", "false"))
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_ENABLED value %q: %w", rawEnabled, err)
+	}
+
+	udpAddr := strings.TrimSpace(env.GetEnv("GELF_UDP_ADDR", ":12201"))
+
+	rawMax := strings.TrimSpace(env.GetEnv("GELF_MAX_MESSAGE_BYTES", "1048576"))
+	maxMessage, err := strconv.Atoi(rawMax)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_MAX_MESSAGE_BYTES value %q: %w", rawMax, err)
+	}
+
+	if maxMessage <= 0 {
+		return Config{}, fmt.Errorf("GELF_MAX_MESSAGE_BYTES must be positive, got %d", maxMessage)
+	}
+
+	// The GELF spec drops incomplete chunked messages after 5 seconds.
+	rawTimeout := strings.TrimSpace(env.GetEnv("GELF_CHUNK_TIMEOUT", "5s"))
+	chunkTimeout, err := time.ParseDuration(rawTimeout)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_CHUNK_TIMEOUT value %q: %w", rawTimeout, err)
+	}
+
+	if chunkTimeout <= 0 {
+		return Config{}, fmt.Errorf("GELF_CHUNK_TIMEOUT must be positive, got %s", chunkTimeout)
+	}
+
+	rawPending := strings.TrimSpace(env.GetEnv("GELF_MAX_PENDING_MESSAGES", "1000"))
+	maxPending, err := strconv.Atoi(rawPending)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_MAX_PENDING_MESSAGES value %q: %w", rawPending, err)
+	}
+
+	if maxPending <= 0 {
+		return Config{}, fmt.Errorf("GELF_MAX_PENDING_MESSAGES must be positive, got %d", maxPending)
+	}
+
+	rawBatch := strings.TrimSpace(env.GetEnv("GELF_BATCH_SIZE", "500"))
+	batchSize, err := strconv.Atoi(rawBatch)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_BATCH_SIZE value %q: %w", rawBatch, err)
+	}
+
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("GELF_BATCH_SIZE must be positive, got %d", batchSize)
+	}
+
+	rawFlush := strings.TrimSpace(env.GetEnv("GELF_FLUSH_INTERVAL", "1s"))
+	flushInterval, err := time.ParseDuration(rawFlush)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid GELF_FLUSH_INTERVAL value %q: %w", rawFlush, err)
+	}
+
+	if flushInterval <= 0 {
+		return Config{}, fmt.Errorf("GELF_FLUSH_INTERVAL must be positive, got %s", flushInterval)
+	}
+
+	return Config{
+		Enabled:            enabled,
+		UDPAddr:            udpAddr,
+		MaxMessageBytes:    maxMessage,
+		ChunkTimeout:       chunkTimeout,
+		MaxPendingMessages: maxPending,
+		BatchSize:          batchSize,
+		FlushInterval:      flushInterval,
+	}, nil
+}
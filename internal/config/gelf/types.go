@@ -0,0 +1,13 @@
+package gelf
+
+import "time"
+
+type Config struct {
+	Enabled            bool
+	UDPAddr            string // empty disables the UDP listener
+	MaxMessageBytes    int    // limit of a reassembled and of a decompressed message
+	ChunkTimeout       time.Duration
+	MaxPendingMessages int // chunked messages being reassembled at once
+	BatchSize          int
+	FlushInterval      time.Duration
+}
@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawBatch := strings.TrimSpace(env.GetEnv("INGEST_MAX_BATCH_SIZE", "1000"))
+	maxBatch, err := strconv.Atoi(rawBatch)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid INGEST_MAX_BATCH_SIZE value %q: %w", rawBatch, err)
+	}
+
+	if maxBatch <= 0 {
+		return Config{}, fmt.Errorf("INGEST_MAX_BATCH_SIZE must be positive, got %d", maxBatch)
+	}
+
+	rawChunk := strings.TrimSpace(env.GetEnv("INGEST_STREAM_CHUNK_SIZE", "500"))
+	chunkSize, err := strconv.Atoi(rawChunk)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid INGEST_STREAM_CHUNK_SIZE value %q: %w", rawChunk, err)
+	}
+
+	if chunkSize <= 0 {
+		return Config{}, fmt.Errorf("INGEST_STREAM_CHUNK_SIZE must be positive, got %d", chunkSize)
+	}
+
+	rawLine := strings.TrimSpace(env.GetEnv("INGEST_MAX_LINE_BYTES", "1048576"))
+	maxLine, err := strconv.Atoi(rawLine)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid INGEST_MAX_LINE_BYTES value %q: %w", rawLine, err)
+	}
+
+	if maxLine <= 0 {
+		return Config{}, fmt.Errorf("INGEST_MAX_LINE_BYTES must be positive, got %d", maxLine)
+	}
+
+	rawPayload := strings.TrimSpace(env.GetEnv("INGEST_MAX_PAYLOAD_BYTES", "16777216"))
+	maxPayload, err := strconv.Atoi(rawPayload)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid INGEST_MAX_PAYLOAD_BYTES value %q: %w", rawPayload, err)
+	}
+
+	if maxPayload <= 0 {
+		return Config{}, fmt.Errorf("INGEST_MAX_PAYLOAD_BYTES must be positive, got %d", maxPayload)
+	}
+
+	rawWindow := strings.TrimSpace(env.GetEnv("INGEST_DEDUP_WINDOW", "0s"))
+	dedupWindow, err := time.ParseDuration(rawWindow)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid INGEST_DEDUP_WINDOW value %q: %w", rawWindow, err)
+	}
+
+	if dedupWindow < 0 {
+		return Config{}, fmt.Errorf("INGEST_DEDUP_WINDOW must not be negative, got %s", dedupWindow)
+	}
+
+	return Config{
+		MaxBatchSize:    maxBatch,
+		StreamChunkSize: chunkSize,
+		MaxLineBytes:    maxLine,
+		MaxPayloadBytes: maxPayload,
+		DedupWindow:     dedupWindow,
+	}, nil
+}
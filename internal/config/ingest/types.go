@@ -0,0 +1,11 @@
+package ingest
+
+import "time"
+
+type Config struct {
+	MaxBatchSize    int
+	StreamChunkSize int
+	MaxLineBytes    int
+	MaxPayloadBytes int           // decoded body limit of the push endpoints
+	DedupWindow     time.Duration // 0 disables duplicate suppression
+}
@@ -2,21 +2,34 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/julian-richter/ApiTemplate/internal/config/app"
+	"github.com/julian-richter/ApiTemplate/internal/config/archive"
 	"github.com/julian-richter/ApiTemplate/internal/config/cache"
 	"github.com/julian-richter/ApiTemplate/internal/config/database"
+	"github.com/julian-richter/ApiTemplate/internal/config/gelf"
+	"github.com/julian-richter/ApiTemplate/internal/config/ingest"
+	"github.com/julian-richter/ApiTemplate/internal/config/partition"
+	"github.com/julian-richter/ApiTemplate/internal/config/retention"
+	"github.com/julian-richter/ApiTemplate/internal/config/syslog"
 )
 
 // Config represents the top-level configuration.
 type Config struct {
-	Cache    cache.Config
-	Database database.Config
-	App      app.Config
+	Cache     cache.Config
+	Database  database.Config
+	App       app.Config
+	Ingest    ingest.Config
+	Partition partition.Config
+	Retention retention.Config
+	Archive   archive.Config
+	Syslog    syslog.Config
+	Gelf      gelf.Config
 }
 
 // Load initializes and returns the top-level configuration by aggregating
-// cache, database, and application configurations.
+// cache, database, application, ingestion, partitioning, retention, archive, syslog and GELF configurations.
 func Load() (Config, error) {
 	// Load environment variables (optional env file)
 	LoadEnv()
@@ -36,9 +49,66 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("failed to load application config: %w", err)
 	}
 
+	ingestCfg, err := ingest.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load ingest config: %w", err)
+	}
+
+	partitionCfg, err := partition.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load partition config: %w", err)
+	}
+
+	retentionCfg, err := retention.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load retention config: %w", err)
+	}
+
+	archiveCfg, err := archive.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load archive config: %w", err)
+	}
+
+	if err := checkRetentionArchive(retentionCfg, archiveCfg); err != nil {
+		return Config{}, err
+	}
+
+	syslogCfg, err := syslog.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load syslog config: %w", err)
+	}
+
+	gelfCfg, err := gelf.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load gelf config: %w", err)
+	}
+
 	return Config{
-		Cache:    cacheCfg,
-		Database: dbCfg,
-		App:      appCfg,
+		Cache:     cacheCfg,
+		Database:  dbCfg,
+		App:       appCfg,
+		Ingest:    ingestCfg,
+		Partition: partitionCfg,
+		Retention: retentionCfg,
+		Archive:   archiveCfg,
+		Syslog:    syslogCfg,
+		Gelf:      gelfCfg,
 	}, nil
 }
+
+// checkRetentionArchive makes sure retention never deletes entries before
+// the archiver had the chance to store them. The archiver works in whole
+// UTC days, so an entry is archived up to a day after ARCHIVE_AFTER.
+func checkRetentionArchive(retentionCfg retention.Config, archiveCfg archive.Config) error {
+	if !retentionCfg.Enabled || !archiveCfg.Enabled {
+		return nil
+	}
+	minAge := archiveCfg.After + 24*time.Hour
+	for _, r := range retentionCfg.Rules {
+		if r.MaxAge < minAge {
+			return fmt.Errorf("retention rule %s:%s=%s deletes entries before they are archived, it must be at least ARCHIVE_AFTER plus one day (%s)",
+				r.Service, r.Level, r.MaxAge, minAge)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package partition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawEnabled := strings.TrimSpace(env.GetEnv("PARTITION_ENABLED", "false"))
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid PARTITION_ENABLED value %q: %w", rawEnabled, err)
+	}
+
+	interval := Interval(strings.ToLower(strings.TrimSpace(env.GetEnv("PARTITION_INTERVAL", "monthly"))))
+	if !interval.Valid() {
+		return Config{}, fmt.Errorf("invalid PARTITION_INTERVAL: %q (valid: %q or %q)", interval, IntervalDaily, IntervalMonthly)
+	}
+
+	rawPremake := strings.TrimSpace(env.GetEnv("PARTITION_PREMAKE", "3"))
+	premake, err := strconv.Atoi(rawPremake)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid PARTITION_PREMAKE value %q: %w", rawPremake, err)
+	}
+
+	if premake < 1 {
+		return Config{}, fmt.Errorf("PARTITION_PREMAKE must be at least 1, got %d", premake)
+	}
+
+	rawCheck := strings.TrimSpace(env.GetEnv("PARTITION_CHECK_INTERVAL", "1h"))
+	checkInterval, err := time.ParseDuration(rawCheck)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid PARTITION_CHECK_INTERVAL value %q: %w", rawCheck, err)
+	}
+
+	if checkInterval <= 0 {
+		return Config{}, fmt.Errorf("PARTITION_CHECK_INTERVAL must be positive, got %s", checkInterval)
+	}
+
+	return Config{
+		Enabled:       enabled,
+		Interval:      interval,
+		Premake:       premake,
+		CheckInterval: checkInterval,
+	}, nil
+}
@@ -0,0 +1,21 @@
+package partition
+
+import "time"
+
+type Interval string
+
+const (
+	IntervalDaily   Interval = "daily"
+	IntervalMonthly Interval = "monthly"
+)
+
+func (i Interval) Valid() bool {
+	return i == IntervalDaily || i == IntervalMonthly
+}
+
+type Config struct {
+	Enabled       bool
+	Interval      Interval
+	Premake       int
+	CheckInterval time.Duration
+}
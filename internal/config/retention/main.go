@@ -0,0 +1,104 @@
+package retention
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawEnabled := strings.TrimSpace(env.GetEnv("RETENTION_ENABLED", "false"))
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RETENTION_ENABLED value %q: %w", rawEnabled, err)
+	}
+
+	rawDryRun := strings.TrimSpace(env.GetEnv("RETENTION_DRY_RUN", "false"))
+	dryRun, err := strconv.ParseBool(rawDryRun)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RETENTION_DRY_RUN value %q: %w", rawDryRun, err)
+	}
+
+	rawInterval := strings.TrimSpace(env.GetEnv("RETENTION_INTERVAL", "1h"))
+	interval, err := time.ParseDuration(rawInterval)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RETENTION_INTERVAL value %q: %w", rawInterval, err)
+	}
+
+	if interval <= 0 {
+		return Config{}, fmt.Errorf("RETENTION_INTERVAL must be positive, got %s", interval)
+	}
+
+	rawBatch := strings.TrimSpace(env.GetEnv("RETENTION_BATCH_SIZE", "1000"))
+	batchSize, err := strconv.Atoi(rawBatch)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RETENTION_BATCH_SIZE value %q: %w", rawBatch, err)
+	}
+
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("RETENTION_BATCH_SIZE must be positive, got %d", batchSize)
+	}
+
+	rules, err := ParseRules(env.GetEnv("RETENTION_POLICIES", ""))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid RETENTION_POLICIES: %w", err)
+	}
+
+	return Config{
+		Enabled:   enabled,
+		DryRun:    dryRun,
+		Interval:  interval,
+		BatchSize: batchSize,
+		Rules:     rules,
+	}, nil
+}
+
+// ParseRules parses a comma-separated policy list such as
+// "debug=3d,info=30d,error=365d,billing:*=7y,*=90d". Each rule is
+// [service:]level=age, where level and service may be "*" and age is
+// anything env.ParseAge accepts.
+func ParseRules(raw string) ([]Rule, error) {
+	var rules []Rule
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		selector, rawAge, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: expected [service:]level=age", part)
+		}
+
+		rule := Rule{Service: Wildcard, Level: strings.ToLower(strings.TrimSpace(selector))}
+		if service, level, ok := strings.Cut(selector, ":"); ok {
+			rule.Service = strings.TrimSpace(service)
+			rule.Level = strings.ToLower(strings.TrimSpace(level))
+		}
+		if rule.Service == "" || rule.Level == "" {
+			return nil, fmt.Errorf("rule %q: empty service or level", part)
+		}
+
+		age, err := env.ParseAge(strings.TrimSpace(rawAge))
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", part, err)
+		}
+		rule.MaxAge = age
+
+		key := rule.Service + ":" + rule.Level
+		if seen[key] {
+			return nil, fmt.Errorf("rule %q: duplicate selector", part)
+		}
+		seen[key] = true
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
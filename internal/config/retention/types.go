@@ -0,0 +1,21 @@
+package retention
+
+import "time"
+
+// Wildcard matches any service or level in a Rule.
+const Wildcard = "*"
+
+// Rule keeps entries of Service and Level for MaxAge. Either may be Wildcard.
+type Rule struct {
+	Service string
+	Level   string
+	MaxAge  time.Duration
+}
+
+type Config struct {
+	Enabled   bool
+	DryRun    bool
+	Interval  time.Duration
+	BatchSize int
+	Rules     []Rule
+}
@@ -0,0 +1,75 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/julian-richter/ApiTemplate/pkg"
+)
+
+// Load initializes a Config struct by fetching environment variables with fallbacks to default values.
+func Load() (Config, error) {
+	rawEnabled := strings.TrimSpace(env.GetEnv("SYSLOG_ENABLED", "false"))
+	enabled, err := strconv.ParseBool(rawEnabled)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SYSLOG_ENABLED value %q: %w", rawEnabled, err)
+	}
+
+	udpAddr := strings.TrimSpace(env.GetEnv("SYSLOG_UDP_ADDR", ":5514"))
+	tcpAddr := strings.TrimSpace(env.GetEnv("SYSLOG_TCP_ADDR", ":5514"))
+	if enabled && udpAddr == "" && tcpAddr == "" {
+		return Config{}, fmt.Errorf("SYSLOG_ENABLED requires SYSLOG_UDP_ADDR or SYSLOG_TCP_ADDR")
+	}
+
+	rawMax := strings.TrimSpace(env.GetEnv("SYSLOG_MAX_MESSAGE_BYTES", "65536"))
+	maxMessage, err := strconv.Atoi(rawMax)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SYSLOG_MAX_MESSAGE_BYTES value %q: %w", rawMax, err)
+	}
+
+	if maxMessage <= 0 {
+		return Config{}, fmt.Errorf("SYSLOG_MAX_MESSAGE_BYTES must be positive, got %d", maxMessage)
+	}
+
+	rawIdle := strings.TrimSpace(env.GetEnv("SYSLOG_IDLE_TIMEOUT", "5m"))
+	idleTimeout, err := time.ParseDuration(rawIdle)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SYSLOG_IDLE_TIMEOUT value %q: %w", rawIdle, err)
+	}
+
+	if idleTimeout <= 0 {
+		return Config{}, fmt.Errorf("SYSLOG_IDLE_TIMEOUT must be positive, got %s", idleTimeout)
+	}
+
+	rawBatch := strings.TrimSpace(env.GetEnv("SYSLOG_BATCH_SIZE", "500"))
+	batchSize, err := strconv.Atoi(rawBatch)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SYSLOG_BATCH_SIZE value %q: %w", rawBatch, err)
+	}
+
+	if batchSize <= 0 {
+		return Config{}, fmt.Errorf("SYSLOG_BATCH_SIZE must be positive, got %d", batchSize)
+	}
+
+	rawFlush := strings.TrimSpace(env.GetEnv("SYSLOG_FLUSH_INTERVAL", "1s"))
+	flushInterval, err := time.ParseDuration(rawFlush)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid SYSLOG_FLUSH_INTERVAL value %q: %w", rawFlush, err)
+	}
+
+	if flushInterval <= 0 {
+		return Config{}, fmt.Errorf("SYSLOG_FLUSH_INTERVAL must be positive, got %s", flushInterval)
+	}
+
+	return Config{
+		Enabled:         enabled,
+		UDPAddr:         udpAddr,
+		TCPAddr:         tcpAddr,
+		MaxMessageBytes: maxMessage,
+		IdleTimeout:     idleTimeout,
+		BatchSize:       batchSize,
+		FlushInterval:   flushInterval,
+	}, nil
+}
@@ -0,0 +1,13 @@
+package syslog
+
+import "time"
+
+type Config struct {
+	Enabled         bool
+	UDPAddr         string // empty disables the UDP listener
+	TCPAddr         string // empty disables the TCP listener
+	MaxMessageBytes int
+	IdleTimeout     time.Duration // TCP connections idle longer are closed
+	BatchSize       int
+	FlushInterval   time.Duration
+}
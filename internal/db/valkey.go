@@ -18,6 +18,7 @@ type ValkeyClient struct {
 type ValkeyClientInterface interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
 	Close()
 }
 
@@ -62,6 +63,15 @@ func (v *ValkeyClient) Set(ctx context.Context, key, value string, ttl time.Dura
 	return resp.Error()
 }
 
+// Del removes the given keys. Missing keys are not an error.
+func (v *ValkeyClient) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	resp := v.client.Do(ctx, v.client.B().Del().Key(keys...).Build())
+	return resp.Error()
+}
+
 // Close implements ValkeyClientInterface.
 func (v *ValkeyClient) Close() {
 	v.client.Close()
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// flushTimeout bounds a single save of the batcher.
+const flushTimeout = 30 * time.Second
+
+// SaveFunc persists one batch of entries and returns the number written.
+type SaveFunc func(ctx context.Context, entries []*model.LogEntry) (int64, error)
+
+// Batcher collects entries from listeners such as the syslog receiver and
+// saves them in batches of up to size entries, at least every interval.
+type Batcher struct {
+	name     string
+	save     SaveFunc
+	size     int
+	interval time.Duration
+	entries  chan *model.LogEntry
+}
+
+// NewBatcher creates a batcher; name only appears in log messages.
+func NewBatcher(name string, save SaveFunc, size int, interval time.Duration) *Batcher {
+	return &Batcher{
+		name:     name,
+		save:     save,
+		size:     size,
+		interval: interval,
+		entries:  make(chan *model.LogEntry, size*4),
+	}
+}
+
+// Add queues an entry. It blocks while the queue is full, which slows down
+// stream senders instead of dropping their entries, and returns ctx.Err()
+// if ctx ends first.
+func (b *Batcher) Add(ctx context.Context, entry *model.LogEntry) error {
+	select {
+	case b.entries <- entry:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run saves queued entries until ctx is cancelled, then saves what is
+// still queued and returns.
+func (b *Batcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]*model.LogEntry, 0, b.size)
+	for {
+		select {
+		case e := <-b.entries:
+			batch = append(batch, e)
+			if len(batch) >= b.size {
+				batch = b.flush(batch)
+			}
+		case <-ticker.C:
+			batch = b.flush(batch)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-b.entries:
+					batch = append(batch, e)
+					if len(batch) >= b.size {
+						batch = b.flush(batch)
+					}
+				default:
+					b.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush saves batch and returns it emptied. If the batch fails, its
+// entries are saved one by one so that a single bad entry does not take
+// the others down. Entries that still fail are dropped, since listeners
+// have nobody to report the error to.
+func (b *Batcher) flush(batch []*model.LogEntry) []*model.LogEntry {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	if _, err := b.save(ctx, batch); err == nil {
+		return batch[:0]
+	} else if len(batch) == 1 {
+		log.Printf("[warning] %s: dropped 1 entry, save failed: %v", b.name, err)
+		return batch[:0]
+	}
+
+	// The retries share the remaining time, so an unreachable database
+	// fails them fast instead of stalling the queue once per entry.
+	var dropped int
+	var firstErr error
+	for i := range batch {
+		if _, err := b.save(ctx, batch[i:i+1]); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Printf("[warning] %s: dropped %d of %d entries, save failed: %v", b.name, dropped, len(batch), firstErr)
+	}
+	return batch[:0]
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+func TestBatcherFlushRetriesRowByRow(t *testing.T) {
+	var saved []string
+	save := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+		for _, e := range entries {
+			if e.Message == "bad" {
+				return 0, errors.New("invalid byte sequence")
+			}
+		}
+		for _, e := range entries {
+			saved = append(saved, e.Message)
+		}
+		return int64(len(entries)), nil
+	}
+
+	b := NewBatcher("test", save, 10, time.Hour)
+	batch := []*model.LogEntry{{Message: "a"}, {Message: "bad"}, {Message: "b"}}
+	if rest := b.flush(batch); len(rest) != 0 {
+		t.Fatalf("flush() left %d entries", len(rest))
+	}
+
+	if len(saved) != 2 || saved[0] != "a" || saved[1] != "b" {
+		t.Errorf("saved %q, want [a b]", saved)
+	}
+}
+
+func TestBatcherRunSavesQueueOnCancel(t *testing.T) {
+	var saved int64
+	save := func(ctx context.Context, entries []*model.LogEntry) (int64, error) {
+		saved += int64(len(entries))
+		return int64(len(entries)), nil
+	}
+
+	b := NewBatcher("test", save, 2, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	for range 5 {
+		if err := b.Add(ctx, &model.LogEntry{Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	b.Run(ctx)
+
+	if saved != 5 {
+		t.Errorf("saved %d entries, want 5", saved)
+	}
+}
+
+func TestStripNUL(t *testing.T) {
+	e := &model.LogEntry{
+		Message: "a\x00b",
+		Host:    "\x00h",
+		Attributes: map[string]any{
+			"k\x00": []any{"x\x00", 1.5, map[string]any{"n": "\x00"}},
+		},
+	}
+	StripNUL(e)
+
+	if e.Message != "ab" || e.Host != "h" {
+		t.Errorf("message %q, host %q", e.Message, e.Host)
+	}
+	list, ok := e.Attributes["k"].([]any)
+	if !ok || list[0] != "x" || list[1] != 1.5 || list[2].(map[string]any)["n"] != "" {
+		t.Errorf("attributes = %#v", e.Attributes)
+	}
+}
@@ -0,0 +1,135 @@
+package gelf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Chunked GELF: every UDP datagram of a chunked message starts with the
+// magic bytes, an 8 byte message ID, the sequence number and the count.
+const (
+	chunkHeaderLen = 12
+	maxChunks      = 128 // the spec's upper bound for the sequence count
+)
+
+var chunkMagic = [2]byte{0x1e, 0x0f}
+
+// errChunkDropped is returned for chunks that cannot be accepted; the
+// message they belong to is discarded.
+var errChunkDropped = errors.New("GELF chunk dropped")
+
+// IsChunk reports whether a datagram is a chunk of a larger message.
+func IsChunk(b []byte) bool {
+	return len(b) >= 2 && b[0] == chunkMagic[0] && b[1] == chunkMagic[1]
+}
+
+type pendingMessage struct {
+	chunks   [][]byte
+	received int
+	size     int
+	deadline time.Time
+}
+
+// Assembler reassembles chunked messages. Incomplete messages are dropped
+// once they are older than timeout; at most maxPending messages are held
+// and each may total at most maxBytes.
+type Assembler struct {
+	timeout    time.Duration
+	maxPending int
+	maxBytes   int
+
+	mu      sync.Mutex
+	pending map[[8]byte]*pendingMessage
+}
+
+// NewAssembler creates an empty assembler.
+func NewAssembler(timeout time.Duration, maxPending, maxBytes int) *Assembler {
+	return &Assembler{
+		timeout:    timeout,
+		maxPending: maxPending,
+		maxBytes:   maxBytes,
+		pending:    map[[8]byte]*pendingMessage{},
+	}
+}
+
+// Add stores a chunk. It returns the joined payload once the last chunk of
+// its message has arrived, nil before that.
+func (a *Assembler) Add(chunk []byte, now time.Time) ([]byte, error) {
+	if len(chunk) < chunkHeaderLen || !IsChunk(chunk) {
+		return nil, fmt.Errorf("%w: malformed header", errChunkDropped)
+	}
+	var id [8]byte
+	copy(id[:], chunk[2:10])
+	seq, count := int(chunk[10]), int(chunk[11])
+	if count == 0 || count > maxChunks || seq >= count {
+		return nil, fmt.Errorf("%w: invalid sequence %d/%d", errChunkDropped, seq, count)
+	}
+	data := chunk[chunkHeaderLen:]
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	msg, ok := a.pending[id]
+	if ok && !now.Before(msg.deadline) {
+		delete(a.pending, id)
+		ok = false
+	}
+	if !ok {
+		if len(a.pending) >= a.maxPending {
+			a.expireLocked(now)
+			if len(a.pending) >= a.maxPending {
+				return nil, fmt.Errorf("%w: %d messages pending", errChunkDropped, len(a.pending))
+			}
+		}
+		msg = &pendingMessage{chunks: make([][]byte, count), deadline: now.Add(a.timeout)}
+		a.pending[id] = msg
+	}
+
+	if len(msg.chunks) != count {
+		delete(a.pending, id)
+		return nil, fmt.Errorf("%w: chunk count changed from %d to %d", errChunkDropped, len(msg.chunks), count)
+	}
+	if msg.chunks[seq] != nil {
+		return nil, nil // duplicate datagram
+	}
+	if msg.size+len(data) > a.maxBytes {
+		delete(a.pending, id)
+		return nil, fmt.Errorf("%w: message exceeds %d bytes", errChunkDropped, a.maxBytes)
+	}
+
+	// The read buffer is reused, so the chunk data has to be copied.
+	msg.chunks[seq] = append([]byte(nil), data...)
+	msg.received++
+	msg.size += len(data)
+	if msg.received < count {
+		return nil, nil
+	}
+
+	delete(a.pending, id)
+	payload := make([]byte, 0, msg.size)
+	for _, c := range msg.chunks {
+		payload = append(payload, c...)
+	}
+	return payload, nil
+}
+
+// Expire drops incomplete messages past their deadline and returns how
+// many were dropped.
+func (a *Assembler) Expire(now time.Time) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.expireLocked(now)
+}
+
+func (a *Assembler) expireLocked(now time.Time) int {
+	n := 0
+	for id, msg := range a.pending {
+		if !now.Before(msg.deadline) {
+			delete(a.pending, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,162 @@
+package gelf
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// chunk builds a chunked GELF datagram for message id.
+func chunk(id byte, seq, count int, data string) []byte {
+	b := []byte{chunkMagic[0], chunkMagic[1], id, 0, 0, 0, 0, 0, 0, 0, byte(seq), byte(count)}
+	return append(b, data...)
+}
+
+func TestIsChunk(t *testing.T) {
+	if !IsChunk(chunk(1, 0, 1, "x")) {
+		t.Error("IsChunk() rejected a chunk")
+	}
+	if IsChunk([]byte(`{"short_message":"x"}`)) || IsChunk([]byte{0x1e}) {
+		t.Error("IsChunk() accepted a plain message")
+	}
+}
+
+func TestAssemblerAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   string
+	}{
+		{name: "single chunk", chunks: [][]byte{chunk(1, 0, 1, "abc")}, want: "abc"},
+		{name: "in order", chunks: [][]byte{chunk(1, 0, 3, "a"), chunk(1, 1, 3, "b"), chunk(1, 2, 3, "c")}, want: "abc"},
+		{name: "reordered", chunks: [][]byte{chunk(1, 2, 3, "c"), chunk(1, 0, 3, "a"), chunk(1, 1, 3, "b")}, want: "abc"},
+		{name: "duplicate ignored", chunks: [][]byte{chunk(1, 0, 2, "a"), chunk(1, 0, 2, "x"), chunk(1, 1, 2, "b")}, want: "ab"},
+		{
+			name:   "interleaved messages",
+			chunks: [][]byte{chunk(1, 0, 2, "a"), chunk(2, 0, 2, "x"), chunk(2, 1, 2, "y"), chunk(1, 1, 2, "b")},
+			want:   "xy", // message 2 completes first
+		},
+	}
+
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAssembler(5*time.Second, 10, 1024)
+			var got []string
+			for _, c := range tt.chunks {
+				payload, err := a.Add(c, now)
+				if err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+				if payload != nil {
+					got = append(got, string(payload))
+				}
+			}
+			if len(got) == 0 || got[0] != tt.want {
+				t.Errorf("payloads = %q, want %q first", got, tt.want)
+			}
+			if len(a.pending) != 0 {
+				t.Errorf("%d messages still pending", len(a.pending))
+			}
+		})
+	}
+}
+
+func TestAssemblerCopiesChunks(t *testing.T) {
+	a := NewAssembler(5*time.Second, 10, 1024)
+	now := time.Now()
+
+	buf := chunk(1, 0, 2, "a")
+	if _, err := a.Add(buf, now); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	buf[chunkHeaderLen] = 'z' // the server reuses its read buffer
+	payload, err := a.Add(chunk(1, 1, 2, "b"), now)
+	if err != nil || string(payload) != "ab" {
+		t.Errorf("Add() = %q, %v, want \"ab\"", payload, err)
+	}
+}
+
+func TestAssemblerRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		before [][]byte
+		chunk  []byte
+	}{
+		{name: "short header", chunk: chunk(1, 0, 1, "")[:chunkHeaderLen-1]},
+		{name: "zero count", chunk: chunk(1, 0, 0, "a")},
+		{name: "sequence past count", chunk: chunk(1, 2, 2, "a")},
+		{name: "too many chunks", chunk: chunk(1, 0, maxChunks+1, "a")},
+		{name: "count changed", before: [][]byte{chunk(1, 0, 3, "a")}, chunk: chunk(1, 1, 2, "b")},
+		{name: "too large", before: [][]byte{chunk(1, 0, 3, "aaaaaa")}, chunk: chunk(1, 1, 3, "bbbbb")},
+		{name: "too many pending", before: [][]byte{chunk(1, 0, 2, "a"), chunk(2, 0, 2, "b")}, chunk: chunk(3, 0, 2, "c")},
+	}
+
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAssembler(5*time.Second, 2, 10)
+			for _, c := range tt.before {
+				if _, err := a.Add(c, now); err != nil {
+					t.Fatalf("Add() setup error = %v", err)
+				}
+			}
+			payload, err := a.Add(tt.chunk, now)
+			if payload != nil || !errors.Is(err, errChunkDropped) {
+				t.Errorf("Add() = %q, %v, want errChunkDropped", payload, err)
+			}
+		})
+	}
+}
+
+func TestAssemblerDropsOversizedMessage(t *testing.T) {
+	a := NewAssembler(5*time.Second, 2, 10)
+	now := time.Now()
+
+	a.Add(chunk(1, 0, 3, "aaaaaa"), now)
+	if _, err := a.Add(chunk(1, 1, 3, "bbbbb"), now); err == nil {
+		t.Fatal("Add() accepted a message over the size limit")
+	}
+	// The rest of the dropped message starts over and cannot complete it.
+	if payload, err := a.Add(chunk(1, 2, 3, "c"), now); payload != nil || err != nil {
+		t.Errorf("Add() = %q, %v after the message was dropped", payload, err)
+	}
+}
+
+func TestAssemblerExpire(t *testing.T) {
+	a := NewAssembler(5*time.Second, 2, 1024)
+	start := time.Now()
+
+	a.Add(chunk(1, 0, 2, "a"), start)
+	a.Add(chunk(2, 0, 2, "x"), start.Add(3*time.Second))
+
+	if n := a.Expire(start.Add(4 * time.Second)); n != 0 {
+		t.Errorf("Expire() before the deadline dropped %d", n)
+	}
+	if n := a.Expire(start.Add(5 * time.Second)); n != 1 {
+		t.Errorf("Expire() at the deadline dropped %d, want 1", n)
+	}
+
+	// Message 1 expired, its last chunk starts a new incomplete message.
+	if payload, _ := a.Add(chunk(1, 1, 2, "b"), start.Add(6*time.Second)); payload != nil {
+		t.Errorf("Add() completed an expired message: %q", payload)
+	}
+	// Message 2 is late as well, even without Expire running in between.
+	if payload, _ := a.Add(chunk(2, 1, 2, "y"), start.Add(9*time.Second)); payload != nil {
+		t.Errorf("Add() completed a message past its deadline: %q", payload)
+	}
+}
+
+func TestAssemblerExpiresWhenFull(t *testing.T) {
+	a := NewAssembler(5*time.Second, 1, 1024)
+	start := time.Now()
+
+	a.Add(chunk(1, 0, 2, "a"), start)
+	if _, err := a.Add(chunk(2, 0, 2, "x"), start.Add(time.Second)); err == nil {
+		t.Fatal("Add() accepted a message over maxPending")
+	}
+	// Once the first message is past its deadline it makes room.
+	if _, err := a.Add(chunk(2, 0, 2, "x"), start.Add(6*time.Second)); err != nil {
+		t.Errorf("Add() error = %v after the pending message expired", err)
+	}
+}
@@ -0,0 +1,243 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+var (
+	// ErrInvalidMessage is returned for payloads that are not a GELF message.
+	ErrInvalidMessage = errors.New("invalid GELF message")
+	// ErrMessageTooLarge is returned when a message exceeds the size limit.
+	ErrMessageTooLarge = errors.New("GELF message too large")
+)
+
+// defaultLevel is the level the GELF spec assumes when none is sent (alert).
+const defaultLevel = 1
+
+// maxTimestamp is the first instant Postgres timestamps and RFC 3339 output
+// cannot represent. It also rejects epochs sent in milliseconds by mistake.
+var maxTimestamp = time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Additional fields that map onto entry fields, in order of preference.
+// Docker's gelf driver sends _container_name, which is the best guess for
+// the service if nothing more specific is set.
+var (
+	serviceFields     = []string{"_service", "_service_name", "_app", "_container_name"}
+	environmentFields = []string{"_environment", "_env"}
+	versionFields     = []string{"_version"}
+	traceIDFields     = []string{"_trace_id"}
+	spanIDFields      = []string{"_span_id"}
+)
+
+// Decompress returns the JSON payload of a GELF message, which may be
+// gzip or zlib compressed or plain. The decompressed size is limited to
+// maxBytes.
+func Decompress(b []byte, maxBytes int) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case len(b) >= 2 && b[0] == 0x78 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	default:
+		if len(b) > maxBytes {
+			return nil, ErrMessageTooLarge
+		}
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, int64(maxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if n > int64(maxBytes) {
+		return nil, ErrMessageTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// Parse decodes the JSON payload of one GELF message and maps it onto a
+// log entry. short_message becomes the message, full_message and the
+// deprecated facility, file and line are kept under the "gelf" attribute,
+// additional fields ("_name") become attributes without the underscore.
+// received stands in for a missing timestamp.
+func Parse(payload []byte, received time.Time, remoteAddr string) (*model.LogEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: payload must be a JSON object", ErrInvalidMessage)
+	}
+	return toEntry(fields, received, remoteAddr)
+}
+
+// ParseStream decodes consecutive JSON messages, as sent to the HTTP input
+// one per line. fn is called for every message in order; decoding stops at
+// the first malformed message.
+func ParseStream(payload []byte, received time.Time, remoteAddr string, fn func(*model.LogEntry, error)) error {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	for {
+		var fields map[string]any
+		err := dec.Decode(&fields)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil || fields == nil {
+			return fmt.Errorf("%w: payload must be JSON objects", ErrInvalidMessage)
+		}
+		fn(toEntry(fields, received, remoteAddr))
+	}
+}
+
+func toEntry(fields map[string]any, received time.Time, remoteAddr string) (*model.LogEntry, error) {
+	short, _ := fields["short_message"].(string)
+	if strings.TrimSpace(short) == "" {
+		return nil, fmt.Errorf("%w: short_message is required", ErrInvalidMessage)
+	}
+
+	level := defaultLevel
+	if v, ok := fields["level"]; ok {
+		n, err := intField(v)
+		if err != nil || n < 0 || n > 7 {
+			return nil, fmt.Errorf("%w: level must be a syslog severity 0-7", ErrInvalidMessage)
+		}
+		level = n
+	}
+
+	ts := received
+	if v, ok := fields["timestamp"]; ok {
+		t, err := timestampField(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		ts = t
+	}
+
+	gelfAttrs := map[string]any{"level": level}
+	for _, key := range []string{"version", "full_message", "facility", "file", "line"} {
+		if v, ok := fields[key]; ok && v != nil {
+			gelfAttrs[key] = v
+		}
+	}
+	if remoteAddr != "" {
+		gelfAttrs["remote_addr"] = remoteAddr
+	}
+
+	additional := map[string]any{}
+	for k, v := range fields {
+		// _id is reserved by the spec.
+		if strings.HasPrefix(k, "_") && k != "_id" && len(k) > 1 {
+			additional[k] = v
+		}
+	}
+
+	entry := &model.LogEntry{
+		Message:     short,
+		Timestamp:   ts.UTC(),
+		Host:        stringField(fields["host"]),
+		Service:     takeString(additional, serviceFields),
+		Environment: takeString(additional, environmentFields),
+		Version:     takeString(additional, versionFields),
+		Attributes:  map[string]any{"gelf": gelfAttrs},
+	}
+	entry.SetSeverity(model.SeverityFromSyslog(level))
+
+	if id := model.NormalizeTraceID(takeString(additional, traceIDFields)); id != "" {
+		if !model.ValidTraceID(id) {
+			return nil, fmt.Errorf("%w: invalid _trace_id %q", ErrInvalidMessage, id)
+		}
+		entry.TraceID = id
+	}
+	if id := model.NormalizeTraceID(takeString(additional, spanIDFields)); id != "" {
+		if !model.ValidSpanID(id) || entry.TraceID == "" {
+			return nil, fmt.Errorf("%w: invalid _span_id %q", ErrInvalidMessage, id)
+		}
+		entry.SpanID = id
+	}
+
+	for k, v := range additional {
+		name := k[1:]
+		if _, reserved := entry.Attributes[name]; !reserved {
+			entry.Attributes[name] = v
+		}
+	}
+	ingest.StripNUL(entry)
+
+	return entry, nil
+}
+
+// takeString removes and returns the first non-empty string field of names.
+func takeString(fields map[string]any, names []string) string {
+	for _, name := range names {
+		if s := stringField(fields[name]); s != "" {
+			delete(fields, name)
+			return s
+		}
+	}
+	return ""
+}
+
+func stringField(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+func intField(v any) (int, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return strconv.Atoi(v.String())
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+// timestampField reads a Unix timestamp in seconds with optional decimal
+// places for milliseconds. It must fall before the year 10000.
+func timestampField(v any) (time.Time, error) {
+	var f float64
+	var err error
+	switch v := v.(type) {
+	case json.Number:
+		f, err = v.Float64()
+	case string:
+		f, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		err = fmt.Errorf("not a number")
+	}
+	if err != nil || f < 0 || f >= float64(maxTimestamp.Unix()) || math.IsNaN(f) {
+		return time.Time{}, fmt.Errorf("timestamp must be Unix seconds before the year 10000, got %v", v)
+	}
+	sec, frac := math.Modf(f)
+	// Round to microseconds, float64 cannot hold nanoseconds of today's epoch.
+	t := time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3)
+	if !t.Before(maxTimestamp) {
+		return time.Time{}, fmt.Errorf("timestamp must be Unix seconds before the year 10000, got %v", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,179 @@
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+func TestParse(t *testing.T) {
+	received := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	payload := `{
+	  "version": "1.1",
+	  "host": "web-1",
+	  "short_message": "disk \u0000full",
+	  "full_message": "stack",
+	  "timestamp": 1714557600.123,
+	  "level": 3,
+	  "_container_name": "api",
+	  "_env": "prod",
+	  "_trace_id": "5B8EFFF798038103D269B633813FC60C",
+	  "_span_id": "eee19b7ec3c1b174",
+	  "_user": "42",
+	  "_gelf": "shadowed",
+	  "_id": "reserved"
+	}`
+
+	e, err := Parse([]byte(payload), received, "10.0.0.1:5000")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if e.Message != "disk full" || e.Host != "web-1" || e.Service != "api" || e.Environment != "prod" {
+		t.Errorf("entry %+v", e)
+	}
+	if want := time.Unix(1714557600, 123000000).UTC(); !e.Timestamp.Equal(want) {
+		t.Errorf("timestamp = %s, want %s", e.Timestamp, want)
+	}
+	if e.Severity != model.SeverityFromSyslog(3) {
+		t.Errorf("severity = %d", e.Severity)
+	}
+	if e.TraceID != "5b8efff798038103d269b633813fc60c" || e.SpanID != "eee19b7ec3c1b174" {
+		t.Errorf("trace %q span %q", e.TraceID, e.SpanID)
+	}
+	if e.Attributes["user"] != "42" {
+		t.Errorf("user = %v", e.Attributes["user"])
+	}
+	if _, ok := e.Attributes["id"]; ok {
+		t.Error("reserved _id was kept")
+	}
+	gelf, _ := e.Attributes["gelf"].(map[string]any)
+	if gelf["full_message"] != "stack" || gelf["remote_addr"] != "10.0.0.1:5000" {
+		t.Errorf("gelf attributes = %v", gelf)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	received := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		payload  string
+		wantTime time.Time
+		wantErr  bool
+	}{
+		{name: "missing timestamp", payload: `{"short_message":"m"}`, wantTime: received},
+		{name: "integer timestamp", payload: `{"short_message":"m","timestamp":1714557600}`, wantTime: time.Unix(1714557600, 0)},
+		{name: "string timestamp", payload: `{"short_message":"m","timestamp":" 1714557600.5 "}`, wantTime: time.Unix(1714557600, 5e8)},
+		{name: "string level", payload: `{"short_message":"m","level":"7"}`, wantTime: received},
+		{name: "negative timestamp", payload: `{"short_message":"m","timestamp":-1}`, wantErr: true},
+		{name: "last second before year 10000", payload: `{"short_message":"m","timestamp":253402300799}`, wantTime: time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)},
+		{name: "timestamp in year 10000", payload: `{"short_message":"m","timestamp":253402300800}`, wantErr: true},
+		{name: "timestamp rounds into year 10000", payload: `{"short_message":"m","timestamp":253402300799.9999999}`, wantErr: true},
+		{name: "millisecond timestamp", payload: `{"short_message":"m","timestamp":1714557600123}`, wantErr: true},
+		{name: "timestamp far beyond year 9999", payload: `{"short_message":"m","timestamp":9.3e12}`, wantErr: true},
+		{name: "timestamp overflows int64", payload: `{"short_message":"m","timestamp":9223372036854775808}`, wantErr: true},
+		{name: "timestamp out of float range", payload: `{"short_message":"m","timestamp":1e400}`, wantErr: true},
+		{name: "timestamp not a number", payload: `{"short_message":"m","timestamp":"NaN"}`, wantErr: true},
+		{name: "timestamp wrong type", payload: `{"short_message":"m","timestamp":true}`, wantErr: true},
+		{name: "level too high", payload: `{"short_message":"m","level":8}`, wantErr: true},
+		{name: "level negative", payload: `{"short_message":"m","level":-1}`, wantErr: true},
+		{name: "level fraction", payload: `{"short_message":"m","level":1.5}`, wantErr: true},
+		{name: "missing short_message", payload: `{"full_message":"m"}`, wantErr: true},
+		{name: "blank short_message", payload: `{"short_message":"  "}`, wantErr: true},
+		{name: "invalid trace_id", payload: `{"short_message":"m","_trace_id":"xyz"}`, wantErr: true},
+		{name: "span_id without trace_id", payload: `{"short_message":"m","_span_id":"eee19b7ec3c1b174"}`, wantErr: true},
+		{name: "not an object", payload: `["short_message"]`, wantErr: true},
+		{name: "null", payload: `null`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse([]byte(tt.payload), received, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidMessage) {
+					t.Errorf("Parse() error = %v, want ErrInvalidMessage", err)
+				}
+				return
+			}
+			if !e.Timestamp.Equal(tt.wantTime) {
+				t.Errorf("timestamp = %s, want %s", e.Timestamp, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	payload := `{"short_message":"a"}
+{"short_message":""}
+{"short_message":"c"}
+{"short_message":`
+
+	var messages []string
+	var rejected int
+	err := ParseStream([]byte(payload), time.Now(), "", func(e *model.LogEntry, err error) {
+		if err != nil {
+			rejected++
+			return
+		}
+		messages = append(messages, e.Message)
+	})
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("ParseStream() error = %v, want ErrInvalidMessage for the truncated message", err)
+	}
+	if strings.Join(messages, ",") != "a,c" || rejected != 1 {
+		t.Errorf("messages = %v, rejected = %d", messages, rejected)
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(`{"short_message":"compressed"}`)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(payload)
+	gw.Close()
+
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(payload)
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		in      []byte
+		limit   int
+		want    []byte
+		wantErr error
+	}{
+		{name: "plain", in: payload, limit: 1024, want: payload},
+		{name: "gzip", in: gz.Bytes(), limit: 1024, want: payload},
+		{name: "zlib", in: zl.Bytes(), limit: 1024, want: payload},
+		{name: "gzip at limit", in: gz.Bytes(), limit: len(payload), want: payload},
+		{name: "plain over limit", in: payload, limit: 8, wantErr: ErrMessageTooLarge},
+		{name: "gzip over limit", in: gz.Bytes(), limit: len(payload) - 1, wantErr: ErrMessageTooLarge},
+		{name: "zlib over limit", in: zl.Bytes(), limit: 8, wantErr: ErrMessageTooLarge},
+		{name: "truncated gzip", in: gz.Bytes()[:gz.Len()-6], limit: 1024, wantErr: ErrInvalidMessage},
+		{name: "gzip header only", in: []byte{0x1f, 0x8b}, limit: 1024, wantErr: ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decompress(tt.in, tt.limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decompress() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(got, tt.want) {
+				t.Errorf("Decompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package gelf
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	gelfcfg "github.com/julian-richter/ApiTemplate/internal/config/gelf"
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+)
+
+// maxDatagramBytes is the largest UDP payload that can be received.
+const maxDatagramBytes = 65535
+
+// Server receives GELF messages over UDP, plain, compressed or chunked,
+// and queues them on a batcher.
+type Server struct {
+	cfg       gelfcfg.Config
+	batcher   *ingest.Batcher
+	assembler *Assembler
+}
+
+// NewServer creates a GELF server writing to batcher.
+func NewServer(cfg config.Config, batcher *ingest.Batcher) *Server {
+	return &Server{
+		cfg:       cfg.Gelf,
+		batcher:   batcher,
+		assembler: NewAssembler(cfg.Gelf.ChunkTimeout, cfg.Gelf.MaxPendingMessages, cfg.Gelf.MaxMessageBytes),
+	}
+}
+
+// Start binds the UDP listener and serves it in the background until ctx
+// is cancelled. Without a UDP address it does nothing.
+func (s *Server) Start(ctx context.Context) error {
+	if s.cfg.UDPAddr == "" {
+		return nil
+	}
+
+	conn, err := net.ListenPacket("udp", s.cfg.UDPAddr)
+	if err != nil {
+		return fmt.Errorf("gelf: listen udp %s: %w", s.cfg.UDPAddr, err)
+	}
+
+	go s.serveUDP(ctx, conn)
+	go s.expireChunks(ctx)
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	return nil
+}
+
+// serveUDP handles one message or chunk per datagram.
+func (s *Server) serveUDP(ctx context.Context, conn net.PacketConn) {
+	buf := make([]byte, maxDatagramBytes)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[warning] gelf: udp read failed: %v", err)
+				continue
+			}
+			return
+		}
+
+		payload := buf[:n]
+		if IsChunk(payload) {
+			payload, err = s.assembler.Add(payload, time.Now())
+			if err != nil {
+				log.Printf("[warning] gelf: %v (from %s)", err, addr)
+				continue
+			}
+			if payload == nil {
+				continue
+			}
+		}
+		s.handle(ctx, payload, addr.String())
+	}
+}
+
+// expireChunks drops incomplete chunked messages once they time out.
+func (s *Server) expireChunks(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ChunkTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n := s.assembler.Expire(now); n > 0 {
+				log.Printf("[warning] gelf: dropped %d incomplete chunked messages", n)
+			}
+		}
+	}
+}
+
+// handle decodes one complete message and queues the entry.
+func (s *Server) handle(ctx context.Context, payload []byte, remote string) {
+	data, err := Decompress(payload, s.cfg.MaxMessageBytes)
+	if err != nil {
+		log.Printf("[warning] gelf: %v (from %s)", err, remote)
+		return
+	}
+	entry, err := Parse(data, time.Now().UTC(), remote)
+	if err != nil {
+		log.Printf("[warning] gelf: %v (from %s)", err, remote)
+		return
+	}
+	_ = s.batcher.Add(ctx, entry)
+}
@@ -0,0 +1,177 @@
+package loki
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// Labels that map onto entry fields, in order of preference. Promtail
+// usually only sets job, so it is the last resort for the service.
+var (
+	serviceLabels     = []string{"service_name", "service", "app", "application", "job"}
+	hostLabels        = []string{"host", "hostname", "node_name"}
+	environmentLabels = []string{"environment", "env"}
+	versionLabels     = []string{"version"}
+	levelLabels       = []string{"level", "detected_level", "severity", "lvl"}
+)
+
+// unixEpoch is the timestamp of entries whose timestamp is zero on the wire.
+var unixEpoch = time.Unix(0, 0)
+
+// Structured metadata keys that carry trace correlation.
+var (
+	traceIDKeys = []string{"trace_id", "traceID", "traceid"}
+	spanIDKeys  = []string{"span_id", "spanID", "spanid"}
+)
+
+// Decode decodes a push body according to its Content-Type. limit bounds
+// the decompressed size of protobuf bodies. ok is false for unsupported
+// content types.
+func Decode(body []byte, contentType string, limit int) (req *PushRequest, ok bool, err error) {
+	mt, _, parseErr := mime.ParseMediaType(contentType)
+	if parseErr != nil {
+		mt = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	switch mt {
+	case ContentTypeProtobuf, "":
+		req, err = DecodeProtobuf(body, limit)
+	case ContentTypeJSON:
+		req, err = DecodeJSON(body)
+	default:
+		return nil, false, nil
+	}
+	return req, true, err
+}
+
+// Entries maps the streams of req onto log entries. Known labels become
+// entry fields, the remaining ones are kept under attributes["labels"] and
+// structured metadata under attributes["metadata"]. now stands in for
+// lines without a timestamp or with the Unix epoch, which is what clients
+// send for an unset one.
+func (req *PushRequest) Entries(now time.Time) (entries []*model.LogEntry, rejected int, message string) {
+	for _, s := range req.Streams {
+		labels := make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+
+		service := takeLabel(labels, serviceLabels)
+		host := takeLabel(labels, hostLabels)
+		environment := takeLabel(labels, environmentLabels)
+		version := takeLabel(labels, versionLabels)
+
+		severity := model.SeverityInfo
+		for _, name := range levelLabels {
+			if sev, err := model.ParseSeverity(labels[name]); err == nil {
+				severity = sev
+				delete(labels, name)
+				break
+			}
+		}
+
+		for i, e := range s.Entries {
+			ts := e.Timestamp
+			if ts.IsZero() || ts.Equal(unixEpoch) {
+				ts = now
+			}
+			entry := &model.LogEntry{
+				Message:     e.Line,
+				Timestamp:   ts,
+				Attributes:  map[string]any{},
+				Service:     service,
+				Host:        host,
+				Environment: environment,
+				Version:     version,
+			}
+			entry.SetSeverity(severity)
+
+			if err := applyMetadata(entry, e.Metadata); err != nil {
+				rejected++
+				if message == "" {
+					message = fmt.Sprintf("stream %s entry %d: %v", labelString(s.Labels), i, err)
+				}
+				continue
+			}
+			if entry.Message == "" {
+				rejected++
+				if message == "" {
+					message = fmt.Sprintf("stream %s entry %d: empty log line", labelString(s.Labels), i)
+				}
+				continue
+			}
+
+			if len(labels) > 0 {
+				attrs := make(map[string]any, len(labels))
+				for k, v := range labels {
+					attrs[k] = v
+				}
+				entry.Attributes["labels"] = attrs
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, rejected, message
+}
+
+// applyMetadata sets trace correlation from structured metadata and keeps
+// the other keys as attributes.
+func applyMetadata(entry *model.LogEntry, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	rest := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		rest[k] = v
+	}
+
+	if id := model.NormalizeTraceID(takeLabel(rest, traceIDKeys)); id != "" {
+		if !model.ValidTraceID(id) {
+			return fmt.Errorf("invalid trace_id %q, expected 32 hex characters", id)
+		}
+		entry.TraceID = id
+	}
+	if id := model.NormalizeTraceID(takeLabel(rest, spanIDKeys)); id != "" {
+		if !model.ValidSpanID(id) {
+			return fmt.Errorf("invalid span_id %q, expected 16 hex characters", id)
+		}
+		if entry.TraceID == "" {
+			return fmt.Errorf("span_id requires trace_id")
+		}
+		entry.SpanID = id
+	}
+
+	if len(rest) > 0 {
+		attrs := make(map[string]any, len(rest))
+		for k, v := range rest {
+			attrs[k] = v
+		}
+		entry.Attributes["metadata"] = attrs
+	}
+	return nil
+}
+
+// takeLabel removes and returns the first non-empty label of names.
+func takeLabel(labels map[string]string, names []string) string {
+	for _, name := range names {
+		if v := labels[name]; v != "" {
+			delete(labels, name)
+			return v
+		}
+	}
+	return ""
+}
+
+func labelString(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for k, v := range labels {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(parts)
+	return "{" + strings.Join(parts, ", ") + "}"
+}
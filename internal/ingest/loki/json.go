@@ -0,0 +1,78 @@
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// jsonRequest mirrors the JSON push format:
+//
+//	{"streams": [{"stream": {"job": "app"}, "values": [["<unix ns>", "line", {"trace_id": "..."}]]}]}
+type jsonRequest struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+// DecodeJSON decodes a JSON push request.
+func DecodeJSON(b []byte) (*PushRequest, error) {
+	var jr jsonRequest
+	if err := json.Unmarshal(b, &jr); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	req := &PushRequest{Streams: make([]Stream, 0, len(jr.Streams))}
+	for i, js := range jr.Streams {
+		s := Stream{Labels: js.Stream, Entries: make([]Entry, 0, len(js.Values))}
+		if s.Labels == nil {
+			s.Labels = map[string]string{}
+		}
+		for name := range s.Labels {
+			if !validLabelName(name) {
+				return nil, fmt.Errorf("%w: stream %d: invalid label name %q", ErrInvalidPayload, i, name)
+			}
+		}
+
+		for j, v := range js.Values {
+			e, err := decodeJSONValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: stream %d value %d: %v", ErrInvalidPayload, i, j, err)
+			}
+			s.Entries = append(s.Entries, e)
+		}
+		req.Streams = append(req.Streams, s)
+	}
+	return req, nil
+}
+
+// decodeJSONValue decodes one ["<unix ns>", "line"(, {metadata})] tuple.
+func decodeJSONValue(v []json.RawMessage) (Entry, error) {
+	var e Entry
+	if len(v) != 2 && len(v) != 3 {
+		return e, fmt.Errorf("expected [timestamp, line] or [timestamp, line, metadata], got %d elements", len(v))
+	}
+
+	var raw string
+	if err := json.Unmarshal(v[0], &raw); err != nil {
+		return e, fmt.Errorf("timestamp must be a string of unix nanoseconds")
+	}
+	ns, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return e, fmt.Errorf("invalid timestamp %q", raw)
+	}
+	e.Timestamp = time.Unix(0, ns).UTC()
+
+	if err := json.Unmarshal(v[1], &e.Line); err != nil {
+		return e, fmt.Errorf("line must be a string")
+	}
+
+	if len(v) == 3 {
+		if err := json.Unmarshal(v[2], &e.Metadata); err != nil {
+			return e, fmt.Errorf("structured metadata must be an object of strings")
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,85 @@
+package loki
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseLabels parses a Prometheus label set such as
+// {job="varlogs", host="web-1"} as sent in protobuf streams.
+func ParseLabels(s string) (map[string]string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("labels %q must be enclosed in braces", s)
+	}
+	rest := strings.TrimSpace(s[1 : len(s)-1])
+
+	labels := map[string]string{}
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("labels %q: expected name=\"value\"", s)
+		}
+		name := strings.TrimSpace(rest[:eq])
+		if !validLabelName(name) {
+			return nil, fmt.Errorf("labels %q: invalid label name %q", s, name)
+		}
+
+		rest = strings.TrimSpace(rest[eq+1:])
+		end := quotedEnd(rest)
+		if end < 0 {
+			return nil, fmt.Errorf("labels %q: unterminated value of %q", s, name)
+		}
+		value, err := strconv.Unquote(rest[:end])
+		if err != nil {
+			return nil, fmt.Errorf("labels %q: invalid value of %q: %w", s, name, err)
+		}
+		if _, dup := labels[name]; dup {
+			return nil, fmt.Errorf("labels %q: duplicate label %q", s, name)
+		}
+		labels[name] = value
+
+		rest = strings.TrimSpace(rest[end:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("labels %q: expected ',' after %q", s, name)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return labels, nil
+}
+
+// quotedEnd returns the index after the closing quote of the double-quoted
+// string at the start of s, or -1.
+func quotedEnd(s string) int {
+	if s == "" || s[0] != '"' {
+		return -1
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package loki
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", in: "{}", want: map[string]string{}},
+		{name: "single", in: `{job="varlogs"}`, want: map[string]string{"job": "varlogs"}},
+		{
+			name: "several with spaces",
+			in:   ` { job = "varlogs" , host="web-1",env="prod" } `,
+			want: map[string]string{"job": "varlogs", "host": "web-1", "env": "prod"},
+		},
+		{
+			name: "escapes",
+			in:   `{path="C:\\logs", msg="say \"hi\"\n", sep="a,b}"}`,
+			want: map[string]string{"path": `C:\logs`, "msg": "say \"hi\"\n", "sep": "a,b}"},
+		},
+		{name: "trailing comma", in: `{job="a",}`, want: map[string]string{"job": "a"}},
+		{name: "no braces", in: `job="a"`, wantErr: true},
+		{name: "missing value", in: `{job}`, wantErr: true},
+		{name: "unquoted value", in: `{job=a}`, wantErr: true},
+		{name: "unterminated value", in: `{job="a}`, wantErr: true},
+		{name: "invalid name", in: `{1job="a"}`, wantErr: true},
+		{name: "dotted name", in: `{service.name="a"}`, wantErr: true},
+		{name: "duplicate", in: `{job="a", job="b"}`, wantErr: true},
+		{name: "missing comma", in: `{job="a" host="b"}`, wantErr: true},
+		{name: "invalid escape", in: `{job="\q"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLabels(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabels(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLabels(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package loki
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+)
+
+// Field numbers from pkg/push/push.proto and google/protobuf/timestamp.proto.
+const (
+	fieldRequestStreams = 1
+
+	fieldStreamLabels  = 1
+	fieldStreamEntries = 2
+
+	fieldEntryTimestamp = 1
+	fieldEntryLine      = 2
+	fieldEntryMetadata  = 3
+
+	fieldLabelName  = 1
+	fieldLabelValue = 2
+
+	fieldTimestampSeconds = 1
+	fieldTimestampNanos   = 2
+)
+
+// DecodeProtobuf decodes a snappy block compressed PushRequest. limit
+// bounds the decompressed size.
+func DecodeProtobuf(body []byte, limit int) (*PushRequest, error) {
+	n, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: snappy: %v", ErrInvalidPayload, err)
+	}
+	if n > limit {
+		return nil, fmt.Errorf("%w: decompressed size %d exceeds %d bytes", ErrInvalidPayload, n, limit)
+	}
+	b, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: snappy: %v", ErrInvalidPayload, err)
+	}
+
+	req := &PushRequest{}
+	err = ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Num != fieldRequestStreams || f.Type != protowire.BytesType {
+			return nil
+		}
+		s, err := decodeStream(f.Bytes)
+		if err != nil {
+			return err
+		}
+		req.Streams = append(req.Streams, s)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return req, nil
+}
+
+func decodeStream(b []byte) (Stream, error) {
+	var s Stream
+	var labels string
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case fieldStreamLabels:
+			labels = string(f.Bytes)
+		case fieldStreamEntries:
+			e, err := decodeEntry(f.Bytes)
+			if err != nil {
+				return err
+			}
+			s.Entries = append(s.Entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return s, err
+	}
+	s.Labels, err = ParseLabels(labels)
+	return s, err
+}
+
+func decodeEntry(b []byte) (Entry, error) {
+	var e Entry
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case fieldEntryTimestamp:
+			ts, err := decodeTimestamp(f.Bytes)
+			if err != nil {
+				return err
+			}
+			e.Timestamp = ts
+		case fieldEntryLine:
+			e.Line = string(f.Bytes)
+		case fieldEntryMetadata:
+			var name, value string
+			err := ingest.WalkProto(f.Bytes, func(f ingest.ProtoField) error {
+				if f.Type != protowire.BytesType {
+					return nil
+				}
+				switch f.Num {
+				case fieldLabelName:
+					name = string(f.Bytes)
+				case fieldLabelValue:
+					value = string(f.Bytes)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if e.Metadata == nil {
+				e.Metadata = map[string]string{}
+			}
+			e.Metadata[name] = value
+		}
+		return nil
+	})
+	return e, err
+}
+
+func decodeTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.VarintType {
+			return nil
+		}
+		switch f.Num {
+		case fieldTimestampSeconds:
+			seconds = int64(f.Varint)
+		case fieldTimestampNanos:
+			nanos = int64(int32(f.Varint))
+		}
+		return nil
+	})
+	return time.Unix(seconds, nanos).UTC(), err
+}
@@ -0,0 +1,133 @@
+package loki
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest/prototest"
+)
+
+// pushEntry encodes a logproto.EntryAdapter; ts is omitted if zero.
+func pushEntry(ts time.Time, line string, metadata ...string) []byte {
+	var fields []prototest.Field
+	if !ts.IsZero() {
+		fields = append(fields, prototest.Bytes(fieldEntryTimestamp, prototest.Message(
+			prototest.Varint(fieldTimestampSeconds, uint64(ts.Unix())),
+			prototest.Varint(fieldTimestampNanos, uint64(ts.Nanosecond())),
+		)))
+	}
+	fields = append(fields, prototest.String(fieldEntryLine, line))
+	for i := 0; i+1 < len(metadata); i += 2 {
+		fields = append(fields, prototest.Bytes(fieldEntryMetadata, prototest.Message(
+			prototest.String(fieldLabelName, metadata[i]),
+			prototest.String(fieldLabelValue, metadata[i+1]),
+		)))
+	}
+	return prototest.Message(fields...)
+}
+
+func pushRequest(labels string, entries ...[]byte) []byte {
+	fields := []prototest.Field{prototest.String(fieldStreamLabels, labels)}
+	for _, e := range entries {
+		fields = append(fields, prototest.Bytes(fieldStreamEntries, e))
+	}
+	fields = append(fields, prototest.Varint(3, 42)) // hash field of newer clients, ignored
+	return snappy.Encode(nil, prototest.Message(prototest.Bytes(fieldRequestStreams, prototest.Message(fields...))))
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	ts := time.Date(2024, time.May, 1, 10, 0, 0, 123, time.UTC)
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	body := pushRequest(`{job="api", level="warn", pod="api-1"}`,
+		pushEntry(ts, "slow request", "trace_id", "5B8EFFF798038103D269B633813FC60C", "user", "42"),
+		pushEntry(time.Time{}, "no timestamp"),
+		pushEntry(ts, ""),
+		pushEntry(ts, "bad span", "span_id", "eee19b7ec3c1b174"),
+		prototest.Message( // empty Timestamp message: epoch 0
+			prototest.Bytes(fieldEntryTimestamp, nil),
+			prototest.String(fieldEntryLine, "zero timestamp"),
+		),
+	)
+
+	req, ok, err := Decode(body, "application/x-protobuf", 1<<20)
+	if !ok || err != nil {
+		t.Fatalf("Decode() ok = %t, err = %v", ok, err)
+	}
+
+	entries, rejected, message := req.Entries(now)
+	if rejected != 2 || message == "" {
+		t.Errorf("rejected = %d, message = %q, want 2", rejected, message)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	e := entries[0]
+	if !e.Timestamp.Equal(ts) || e.Message != "slow request" || e.Service != "api" || e.Level != "warn" {
+		t.Errorf("entry %+v", e)
+	}
+	if e.TraceID != "5b8efff798038103d269b633813fc60c" {
+		t.Errorf("trace_id = %q", e.TraceID)
+	}
+	if got := e.Attributes["metadata"].(map[string]any)["user"]; got != "42" {
+		t.Errorf("metadata user = %v", got)
+	}
+	if got := e.Attributes["labels"].(map[string]any)["pod"]; got != "api-1" {
+		t.Errorf("label pod = %v", got)
+	}
+
+	for _, e := range entries[1:] {
+		if !e.Timestamp.Equal(now) {
+			t.Errorf("entry %q got %s, want the receive time", e.Message, e.Timestamp)
+		}
+	}
+}
+
+func TestDecodeProtobufInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  []byte
+		limit int
+	}{
+		{"not snappy", []byte("\xff\xff\xff\xff\xff"), 1 << 20},
+		{"over limit", pushRequest(`{job="a"}`, pushEntry(time.Now(), "line")), 8},
+		{"truncated message", snappy.Encode(nil, []byte{0x0a, 0x10, 0x0a}), 1 << 20},
+		{"invalid labels", pushRequest(`job="a"`, pushEntry(time.Now(), "line")), 1 << 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeProtobuf(tt.body, tt.limit); !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("DecodeProtobuf() error = %v, want ErrInvalidPayload", err)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"valid", `{"streams":[{"stream":{"job":"a"},"values":[["1714557600000000000","line",{"trace_id":"x"}]]}]}`, false},
+		{"no streams", `{}`, false},
+		{"numeric timestamp", `{"streams":[{"stream":{},"values":[[1714557600000000000,"line"]]}]}`, true},
+		{"short tuple", `{"streams":[{"stream":{},"values":[["1"]]}]}`, true},
+		{"invalid label", `{"streams":[{"stream":{"a-b":"c"},"values":[]}]}`, true},
+		{"metadata not strings", `{"streams":[{"stream":{},"values":[["1","line",{"a":1}]]}]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeJSON([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJSON() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package loki
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidPayload is returned for bodies that are not a valid push
+// request in the announced encoding.
+var ErrInvalidPayload = errors.New("invalid Loki push payload")
+
+// Content types of the push API. Promtail and Grafana Agent send snappy
+// compressed protobuf; an empty content type is treated the same way.
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// PushRequest is a decoded logproto.PushRequest.
+type PushRequest struct {
+	Streams []Stream
+}
+
+// Stream is a set of entries sharing one label set.
+type Stream struct {
+	Labels  map[string]string
+	Entries []Entry
+}
+
+// Entry is one log line of a stream. Metadata holds the structured
+// metadata Loki 3 attaches to single lines.
+type Entry struct {
+	Timestamp time.Time // zero if the sender gave none
+	Line      string
+	Metadata  map[string]string
+}
@@ -0,0 +1,238 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// Resource attributes that map onto entry fields instead of attributes.
+const (
+	attrServiceName    = "service.name"
+	attrServiceVersion = "service.version"
+	attrHostName       = "host.name"
+	attrEnvironment    = "deployment.environment.name"
+	attrEnvironmentOld = "deployment.environment"
+)
+
+// Decode decodes a request body according to its Content-Type. ok is false
+// for content types other than OTLP protobuf and JSON.
+func Decode(body []byte, contentType string) (req *Request, ok bool, err error) {
+	switch mediaType(contentType) {
+	case ContentTypeProtobuf:
+		req, err = DecodeProtobuf(body)
+	case ContentTypeJSON:
+		req, err = DecodeJSON(body)
+	default:
+		return nil, false, nil
+	}
+	return req, true, err
+}
+
+// EncodeResponse encodes an ExportLogsServiceResponse in the encoding of
+// the request and returns it with its content type.
+func EncodeResponse(contentType string, rejected int64, message string) ([]byte, string) {
+	if mediaType(contentType) == ContentTypeProtobuf {
+		return encodeProtobufResponse(rejected, message), ContentTypeProtobuf
+	}
+	return encodeJSONResponse(rejected, message), ContentTypeJSON
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
+
+// Entries maps the records of req onto log entries; rejected and message
+// fill the partial success of the response. now stands in for records
+// without any timestamp.
+func (req *Request) Entries(now time.Time) (entries []*model.LogEntry, rejected int64, message string) {
+	for _, rl := range req.ResourceLogs {
+		resource, service, host, environment, version := splitResource(rl.Resource)
+
+		for _, sl := range rl.ScopeLogs {
+			for i := range sl.LogRecords {
+				entry, err := toEntry(&sl.LogRecords[i], now)
+				if err != nil {
+					rejected++
+					if message == "" {
+						message = err.Error()
+					}
+					continue
+				}
+
+				entry.Service, entry.Host, entry.Environment, entry.Version = service, host, environment, version
+				if len(resource) > 0 {
+					entry.Attributes["resource"] = resource
+				}
+				if sl.ScopeName != "" {
+					scope := map[string]any{"name": sl.ScopeName}
+					if sl.ScopeVersion != "" {
+						scope["version"] = sl.ScopeVersion
+					}
+					entry.Attributes["scope"] = scope
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if rejected > 0 {
+		message = fmt.Sprintf("%d log records rejected, first: %s", rejected, message)
+	}
+	return entries, rejected, message
+}
+
+// splitResource takes the provenance fields out of the resource attributes
+// and returns the rest as a nested attribute map.
+func splitResource(kvs []KeyValue) (rest map[string]any, service, host, environment, version string) {
+	rest = map[string]any{}
+	for _, kv := range kvs {
+		s, isString := kv.Value.(string)
+		switch {
+		case kv.Key == attrServiceName && isString:
+			service = s
+		case kv.Key == attrHostName && isString:
+			host = s
+		case kv.Key == attrServiceVersion && isString:
+			version = s
+		case (kv.Key == attrEnvironment || kv.Key == attrEnvironmentOld) && isString:
+			if environment == "" || kv.Key == attrEnvironment {
+				environment = s
+			}
+		default:
+			setAttribute(rest, kv.Key, kv.Value)
+		}
+	}
+	return rest, service, host, environment, version
+}
+
+func toEntry(rec *LogRecord, now time.Time) (*model.LogEntry, error) {
+	if rec.err != nil {
+		return nil, rec.err
+	}
+	entry := &model.LogEntry{Attributes: map[string]any{}}
+
+	switch {
+	case rec.TimeUnixNano != 0:
+		entry.Timestamp = time.Unix(0, int64(rec.TimeUnixNano)).UTC()
+	case rec.ObservedTimeUnixNano != 0:
+		entry.Timestamp = time.Unix(0, int64(rec.ObservedTimeUnixNano)).UTC()
+	default:
+		entry.Timestamp = now
+	}
+
+	switch {
+	case rec.SeverityNumber < 0 || rec.SeverityNumber > 24:
+		return nil, fmt.Errorf("invalid severity number %d", rec.SeverityNumber)
+	case rec.SeverityNumber > 0:
+		entry.SetSeverity(model.SeverityFromOTel(rec.SeverityNumber))
+	default:
+		sev, err := model.ParseSeverity(rec.SeverityText)
+		if err != nil {
+			sev = model.SeverityInfo
+		}
+		entry.SetSeverity(sev)
+	}
+	if rec.SeverityText != "" && !strings.EqualFold(rec.SeverityText, entry.Level) {
+		entry.Attributes["severity_text"] = rec.SeverityText
+	}
+
+	switch body := rec.Body.(type) {
+	case nil:
+		entry.Message = rec.EventName
+	case string:
+		entry.Message = body
+	default:
+		// Structured bodies stay queryable as attributes.
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body: %v", err)
+		}
+		entry.Message = string(b)
+		entry.Attributes["body"] = body
+	}
+	if entry.Message == "" {
+		return nil, fmt.Errorf("empty body")
+	}
+	if rec.EventName != "" {
+		entry.Attributes["event_name"] = rec.EventName
+	}
+
+	if traceID, ok := hexID(rec.TraceID, model.TraceIDLength/2); !ok {
+		return nil, fmt.Errorf("invalid trace_id length %d", len(rec.TraceID))
+	} else {
+		entry.TraceID = traceID
+	}
+	if spanID, ok := hexID(rec.SpanID, model.SpanIDLength/2); !ok {
+		return nil, fmt.Errorf("invalid span_id length %d", len(rec.SpanID))
+	} else {
+		entry.SpanID = spanID
+	}
+	if entry.SpanID != "" && entry.TraceID == "" {
+		return nil, fmt.Errorf("span_id without trace_id")
+	}
+
+	for _, kv := range rec.Attributes {
+		setAttribute(entry.Attributes, kv.Key, kv.Value)
+	}
+
+	return entry, nil
+}
+
+// hexID encodes an ID of size bytes. Empty and all-zero IDs mean "not set".
+func hexID(id []byte, size int) (string, bool) {
+	if len(id) == 0 {
+		return "", true
+	}
+	if len(id) != size {
+		return "", false
+	}
+	for _, b := range id {
+		if b != 0 {
+			return hex.EncodeToString(id), true
+		}
+	}
+	return "", true
+}
+
+// setAttribute stores value under a dotted OTel key as nested objects, so
+// "http.request.method" becomes queryable as attr.http.request.method.
+// If a prefix is already taken by a non-object value, the full key is
+// stored flat instead. A value whose key already holds nested keys is
+// stored under key + "._value".
+func setAttribute(attrs map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	cur := attrs
+	for _, p := range parts[:len(parts)-1] {
+		next, exists := cur[p]
+		if !exists {
+			m := map[string]any{}
+			cur[p] = m
+			cur = m
+			continue
+		}
+		m, isMap := next.(map[string]any)
+		if !isMap {
+			attrs[key] = value
+			return
+		}
+		cur = m
+	}
+
+	last := parts[len(parts)-1]
+	if m, isMap := cur[last].(map[string]any); isMap {
+		// Keep the nested keys, e.g. "http" set after "http.method".
+		m["_value"] = value
+		return
+	}
+	cur[last] = value
+}
@@ -0,0 +1,192 @@
+package otlp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+const jsonRequestBody = `{
+  "resourceLogs": [{
+    "resource": {"attributes": [
+      {"key": "service.name", "value": {"stringValue": "checkout"}},
+      {"key": "host.name", "value": {"stringValue": "web01"}},
+      {"key": "deployment.environment", "value": {"stringValue": "old"}},
+      {"key": "deployment.environment.name", "value": {"stringValue": "prod"}},
+      {"key": "k8s.pod.name", "value": {"stringValue": "pod-1"}}
+    ]},
+    "scopeLogs": [{
+      "scope": {"name": "app.logger", "version": "1.2"},
+      "logRecords": [
+        {
+          "timeUnixNano": "1700000000000000000",
+          "severityNumber": "SEVERITY_NUMBER_WARN2",
+          "severityText": "Warning",
+          "body": {"stringValue": "disk almost full"},
+          "traceId": "5b8efff798038103d269b633813fc60c",
+          "spanId": "eee19b7ec3c1b174",
+          "attributes": [
+            {"key": "http.request.method", "value": {"stringValue": "GET"}},
+            {"key": "retries", "value": {"intValue": "3"}}
+          ]
+        },
+        {
+          "observedTimeUnixNano": 1700000001000000000,
+          "body": {"kvlistValue": {"values": [{"key": "a", "value": {"boolValue": true}}]}}
+        },
+        {"body": {"stringValue": "no time"}},
+        {"body": {"stringValue": "bad trace"}, "traceId": "zz8efff798038103d269b633813fc60c"},
+        {"body": {"stringValue": "short hex trace"}, "traceId": "5b8efff7"},
+        {"body": {"stringValue": "span only"}, "spanId": "eee19b7ec3c1b174"},
+        {"severityNumber": 9}
+      ]
+    }]
+  }]
+}`
+
+func TestDecodeJSONEntries(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	req, ok, err := Decode([]byte(jsonRequestBody), "application/json; charset=utf-8")
+	if !ok || err != nil {
+		t.Fatalf("Decode() ok = %t, err = %v", ok, err)
+	}
+
+	entries, rejected, message := req.Entries(now)
+	if rejected != 4 {
+		t.Errorf("rejected = %d, want 4 (%s)", rejected, message)
+	}
+	if !strings.Contains(message, "invalid trace_id") {
+		t.Errorf("message = %q, want the first rejection (invalid trace_id)", message)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	first := entries[0]
+	if first.Message != "disk almost full" || first.Severity != model.SeverityFromOTel(14) {
+		t.Errorf("first entry message %q, severity %d", first.Message, first.Severity)
+	}
+	if !first.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("first entry timestamp = %s", first.Timestamp)
+	}
+	if first.Service != "checkout" || first.Host != "web01" || first.Environment != "prod" {
+		t.Errorf("provenance = %q %q %q", first.Service, first.Host, first.Environment)
+	}
+	if first.TraceID != "5b8efff798038103d269b633813fc60c" || first.SpanID != "eee19b7ec3c1b174" {
+		t.Errorf("trace %q span %q", first.TraceID, first.SpanID)
+	}
+	wantAttrs := map[string]any{
+		"http":          map[string]any{"request": map[string]any{"method": "GET"}},
+		"retries":       int64(3),
+		"severity_text": "Warning",
+		"resource":      map[string]any{"k8s": map[string]any{"pod": map[string]any{"name": "pod-1"}}},
+		"scope":         map[string]any{"name": "app.logger", "version": "1.2"},
+	}
+	if !reflect.DeepEqual(first.Attributes, wantAttrs) {
+		t.Errorf("attributes = %#v\nwant %#v", first.Attributes, wantAttrs)
+	}
+
+	if second := entries[1]; second.Message != `{"a":true}` || !second.Timestamp.Equal(time.Unix(1700000001, 0)) {
+		t.Errorf("second entry message %q, timestamp %s", second.Message, second.Timestamp)
+	}
+	if third := entries[2]; !third.Timestamp.Equal(now) || third.Level != model.SeverityInfo.String() {
+		t.Errorf("third entry timestamp %s, level %q", third.Timestamp, third.Level)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		wantOK      bool
+		wantErr     bool
+	}{
+		{"json", `{"resourceLogs":[]}`, "application/json", true, false},
+		{"empty protobuf", "", "application/x-protobuf", true, false},
+		{"unsupported type", "{}", "text/plain", false, false},
+		{"malformed json", `{"resourceLogs":`, "application/json", true, true},
+		{"bad severity name", `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"severityNumber":"LOUD"}]}]}]}`, "application/json", true, true},
+		{"truncated protobuf", "\x0a\x05ab", "application/x-protobuf", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, err := Decode([]byte(tt.body), tt.contentType)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() ok = %t, err = %v", ok, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("Decode() error = %v, want ErrInvalidPayload", err)
+			}
+		})
+	}
+}
+
+func TestSetAttribute(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want map[string]any
+	}{
+		{
+			name: "nested",
+			keys: []string{"http.method", "http.status_code"},
+			want: map[string]any{"http": map[string]any{"method": "v0", "status_code": "v1"}},
+		},
+		{
+			name: "prefix taken by a value",
+			keys: []string{"http", "http.method"},
+			want: map[string]any{"http": "v0", "http.method": "v1"},
+		},
+		{
+			name: "value after nested keys",
+			keys: []string{"http.method", "http"},
+			want: map[string]any{"http": map[string]any{"method": "v0", "_value": "v1"}},
+		},
+		{
+			name: "nested value after deeper keys",
+			keys: []string{"a.b.c", "a.b"},
+			want: map[string]any{"a": map[string]any{"b": map[string]any{"c": "v0", "_value": "v1"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]any{}
+			for i, key := range tt.keys {
+				setAttribute(attrs, key, "v"+string(rune('0'+i)))
+			}
+			if !reflect.DeepEqual(attrs, tt.want) {
+				t.Errorf("attributes = %#v, want %#v", attrs, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeResponse(t *testing.T) {
+	tests := []struct {
+		contentType string
+		rejected    int64
+		message     string
+		want        string
+		wantType    string
+	}{
+		{"application/json", 0, "", "{}", ContentTypeJSON},
+		{"application/json", 2, "bad", `{"partialSuccess":{"errorMessage":"bad","rejectedLogRecords":"2"}}`, ContentTypeJSON},
+		{"application/x-protobuf", 0, "", "", ContentTypeProtobuf},
+		{"application/x-protobuf", 2, "bad", "\x0a\x07\x08\x02\x12\x03bad", ContentTypeProtobuf},
+	}
+
+	for _, tt := range tests {
+		got, gotType := EncodeResponse(tt.contentType, tt.rejected, tt.message)
+		if string(got) != tt.want || gotType != tt.wantType {
+			t.Errorf("EncodeResponse(%q, %d, %q) = %q, %q", tt.contentType, tt.rejected, tt.message, got, gotType)
+		}
+	}
+}
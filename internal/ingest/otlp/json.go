@@ -0,0 +1,222 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The json* types mirror the OTLP/JSON encoding (protobuf JSON mapping with
+// hex trace and span IDs).
+
+type jsonRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []jsonKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"scope"`
+			LogRecords []jsonLogRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type jsonLogRecord struct {
+	TimeUnixNano         jsonUint64     `json:"timeUnixNano"`
+	ObservedTimeUnixNano jsonUint64     `json:"observedTimeUnixNano"`
+	SeverityNumber       jsonSeverity   `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 *jsonAnyValue  `json:"body"`
+	Attributes           []jsonKeyValue `json:"attributes"`
+	TraceID              string         `json:"traceId"`
+	SpanID               string         `json:"spanId"`
+	EventName            string         `json:"eventName"`
+}
+
+type jsonKeyValue struct {
+	Key   string        `json:"key"`
+	Value *jsonAnyValue `json:"value"`
+}
+
+type jsonAnyValue struct {
+	StringValue *string     `json:"stringValue"`
+	BoolValue   *bool       `json:"boolValue"`
+	IntValue    *jsonUint64 `json:"intValue"`
+	DoubleValue *float64    `json:"doubleValue"`
+	BytesValue  *string     `json:"bytesValue"` // base64, kept as is
+	ArrayValue  *struct {
+		Values []*jsonAnyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []jsonKeyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+// jsonUint64 accepts 64-bit integers as JSON numbers or decimal strings,
+// which is how the protobuf JSON mapping writes them.
+type jsonUint64 uint64
+
+func (v *jsonUint64) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		*v = jsonUint64(n)
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", b)
+	}
+	*v = jsonUint64(n)
+	return nil
+}
+
+// jsonSeverity accepts a SeverityNumber as integer or enum name such as
+// "SEVERITY_NUMBER_WARN".
+type jsonSeverity int
+
+// severityNumberNames maps the enum name suffixes to their base numbers.
+var severityNumberNames = map[string]int{
+	"UNSPECIFIED": 0, "TRACE": 1, "DEBUG": 5, "INFO": 9, "WARN": 13, "ERROR": 17, "FATAL": 21,
+}
+
+func (v *jsonSeverity) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		*v = jsonSeverity(n)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return fmt.Errorf("invalid severityNumber %s", b)
+	}
+	name = strings.TrimPrefix(name, "SEVERITY_NUMBER_")
+	if name == "" {
+		return fmt.Errorf("invalid severityNumber %s", b)
+	}
+	// TRACE2 is TRACE plus one, and so on.
+	base, offset := name, 0
+	if last := name[len(name)-1:]; len(name) > 1 && last >= "2" && last <= "4" {
+		base, offset = name[:len(name)-1], int(last[0]-'1')
+	}
+	num, ok := severityNumberNames[base]
+	if !ok || (num == 0 && offset > 0) {
+		return fmt.Errorf("invalid severityNumber %s", b)
+	}
+	*v = jsonSeverity(num + offset)
+	return nil
+}
+
+// DecodeJSON decodes an OTLP/JSON ExportLogsServiceRequest.
+func DecodeJSON(b []byte) (*Request, error) {
+	var in jsonRequest
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if err := dec.Decode(&in); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	req := &Request{}
+	for _, jrl := range in.ResourceLogs {
+		rl := ResourceLogs{Resource: convertKeyValues(jrl.Resource.Attributes, 0)}
+		for _, jsl := range jrl.ScopeLogs {
+			sl := ScopeLogs{ScopeName: jsl.Scope.Name, ScopeVersion: jsl.Scope.Version}
+			for _, jr := range jsl.LogRecords {
+				rec := LogRecord{
+					TimeUnixNano:         uint64(jr.TimeUnixNano),
+					ObservedTimeUnixNano: uint64(jr.ObservedTimeUnixNano),
+					SeverityNumber:       int(jr.SeverityNumber),
+					SeverityText:         jr.SeverityText,
+					Body:                 convertAnyValue(jr.Body, 0),
+					Attributes:           convertKeyValues(jr.Attributes, 0),
+					EventName:            jr.EventName,
+				}
+				var traceErr, spanErr error
+				rec.TraceID, traceErr = jsonID(jr.TraceID)
+				rec.SpanID, spanErr = jsonID(jr.SpanID)
+				switch {
+				case traceErr != nil:
+					rec.err = fmt.Errorf("invalid trace_id: %w", traceErr)
+				case spanErr != nil:
+					rec.err = fmt.Errorf("invalid span_id: %w", spanErr)
+				}
+				sl.LogRecords = append(sl.LogRecords, rec)
+			}
+			rl.ScopeLogs = append(rl.ScopeLogs, sl)
+		}
+		req.ResourceLogs = append(req.ResourceLogs, rl)
+	}
+
+	return req, nil
+}
+
+func convertKeyValues(kvs []jsonKeyValue, depth int) []KeyValue {
+	out := make([]KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		out = append(out, KeyValue{Key: kv.Key, Value: convertAnyValue(kv.Value, depth)})
+	}
+	return out
+}
+
+func convertAnyValue(v *jsonAnyValue, depth int) any {
+	if v == nil || depth > maxValueDepth {
+		return nil
+	}
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, item := range v.ArrayValue.Values {
+			values = append(values, convertAnyValue(item, depth+1))
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = convertAnyValue(kv.Value, depth+1)
+		}
+		return values
+	}
+	return nil
+}
+
+// encodeJSONResponse encodes an ExportLogsServiceResponse as OTLP/JSON.
+func encodeJSONResponse(rejected int64, message string) []byte {
+	if rejected == 0 && message == "" {
+		return []byte("{}")
+	}
+	partial := map[string]any{}
+	if rejected != 0 {
+		partial["rejectedLogRecords"] = strconv.FormatInt(rejected, 10)
+	}
+	if message != "" {
+		partial["errorMessage"] = message
+	}
+	b, _ := json.Marshal(map[string]any{"partialSuccess": partial})
+	return b
+}
+
+// jsonID decodes a hex trace or span ID.
+func jsonID(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,269 @@
+package otlp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+)
+
+// Field numbers from opentelemetry/proto/logs/v1/logs.proto and
+// opentelemetry/proto/common/v1/common.proto.
+const (
+	fieldRequestResourceLogs = 1
+
+	fieldResourceLogsResource  = 1
+	fieldResourceLogsScopeLogs = 2
+	fieldResourceAttributes    = 1
+
+	fieldScopeLogsScope      = 1
+	fieldScopeLogsLogRecords = 2
+	fieldScopeName           = 1
+	fieldScopeVersion        = 2
+
+	fieldRecordTime           = 1
+	fieldRecordSeverityNumber = 2
+	fieldRecordSeverityText   = 3
+	fieldRecordBody           = 5
+	fieldRecordAttributes     = 6
+	fieldRecordTraceID        = 9
+	fieldRecordSpanID         = 10
+	fieldRecordObservedTime   = 11
+	fieldRecordEventName      = 12
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldAnyString = 1
+	fieldAnyBool   = 2
+	fieldAnyInt    = 3
+	fieldAnyDouble = 4
+	fieldAnyArray  = 5
+	fieldAnyKVList = 6
+	fieldAnyBytes  = 7
+
+	fieldListValues = 1 // ArrayValue.values and KeyValueList.values
+
+	fieldResponsePartialSuccess = 1
+	fieldPartialRejected        = 1
+	fieldPartialErrorMessage    = 2
+)
+
+// maxValueDepth bounds the nesting of AnyValue arrays and maps.
+const maxValueDepth = 32
+
+// DecodeProtobuf decodes a binary ExportLogsServiceRequest.
+func DecodeProtobuf(b []byte) (*Request, error) {
+	req := &Request{}
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Num == fieldRequestResourceLogs && f.Type == protowire.BytesType {
+			rl, err := decodeResourceLogs(f.Bytes)
+			if err != nil {
+				return err
+			}
+			req.ResourceLogs = append(req.ResourceLogs, rl)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return req, nil
+}
+
+func decodeResourceLogs(b []byte) (ResourceLogs, error) {
+	var rl ResourceLogs
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case fieldResourceLogsResource:
+			return ingest.WalkProto(f.Bytes, func(f ingest.ProtoField) error {
+				if f.Num == fieldResourceAttributes && f.Type == protowire.BytesType {
+					kv, err := decodeKeyValue(f.Bytes, 0)
+					if err != nil {
+						return err
+					}
+					rl.Resource = append(rl.Resource, kv)
+				}
+				return nil
+			})
+		case fieldResourceLogsScopeLogs:
+			sl, err := decodeScopeLogs(f.Bytes)
+			if err != nil {
+				return err
+			}
+			rl.ScopeLogs = append(rl.ScopeLogs, sl)
+		}
+		return nil
+	})
+	return rl, err
+}
+
+func decodeScopeLogs(b []byte) (ScopeLogs, error) {
+	var sl ScopeLogs
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case fieldScopeLogsScope:
+			return ingest.WalkProto(f.Bytes, func(f ingest.ProtoField) error {
+				if f.Type != protowire.BytesType {
+					return nil
+				}
+				switch f.Num {
+				case fieldScopeName:
+					sl.ScopeName = string(f.Bytes)
+				case fieldScopeVersion:
+					sl.ScopeVersion = string(f.Bytes)
+				}
+				return nil
+			})
+		case fieldScopeLogsLogRecords:
+			rec, err := decodeLogRecord(f.Bytes)
+			if err != nil {
+				return err
+			}
+			sl.LogRecords = append(sl.LogRecords, rec)
+		}
+		return nil
+	})
+	return sl, err
+}
+
+func decodeLogRecord(b []byte) (LogRecord, error) {
+	var rec LogRecord
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		switch {
+		case f.Num == fieldRecordTime && f.Type == protowire.Fixed64Type:
+			rec.TimeUnixNano = f.Fixed
+		case f.Num == fieldRecordObservedTime && f.Type == protowire.Fixed64Type:
+			rec.ObservedTimeUnixNano = f.Fixed
+		case f.Num == fieldRecordSeverityNumber && f.Type == protowire.VarintType:
+			rec.SeverityNumber = int(int32(f.Varint))
+		case f.Num == fieldRecordSeverityText && f.Type == protowire.BytesType:
+			rec.SeverityText = string(f.Bytes)
+		case f.Num == fieldRecordBody && f.Type == protowire.BytesType:
+			v, err := decodeAnyValue(f.Bytes, 0)
+			if err != nil {
+				return err
+			}
+			rec.Body = v
+		case f.Num == fieldRecordAttributes && f.Type == protowire.BytesType:
+			kv, err := decodeKeyValue(f.Bytes, 0)
+			if err != nil {
+				return err
+			}
+			rec.Attributes = append(rec.Attributes, kv)
+		case f.Num == fieldRecordTraceID && f.Type == protowire.BytesType:
+			rec.TraceID = f.Bytes
+		case f.Num == fieldRecordSpanID && f.Type == protowire.BytesType:
+			rec.SpanID = f.Bytes
+		case f.Num == fieldRecordEventName && f.Type == protowire.BytesType:
+			rec.EventName = string(f.Bytes)
+		}
+		return nil
+	})
+	return rec, err
+}
+
+func decodeKeyValue(b []byte, depth int) (KeyValue, error) {
+	var kv KeyValue
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		if f.Type != protowire.BytesType {
+			return nil
+		}
+		switch f.Num {
+		case fieldKeyValueKey:
+			kv.Key = string(f.Bytes)
+		case fieldKeyValueValue:
+			v, err := decodeAnyValue(f.Bytes, depth)
+			if err != nil {
+				return err
+			}
+			kv.Value = v
+		}
+		return nil
+	})
+	return kv, err
+}
+
+func decodeAnyValue(b []byte, depth int) (any, error) {
+	if depth > maxValueDepth {
+		return nil, fmt.Errorf("values nested deeper than %d levels", maxValueDepth)
+	}
+
+	var value any
+	err := ingest.WalkProto(b, func(f ingest.ProtoField) error {
+		switch {
+		case f.Num == fieldAnyString && f.Type == protowire.BytesType:
+			value = string(f.Bytes)
+		case f.Num == fieldAnyBool && f.Type == protowire.VarintType:
+			value = f.Varint != 0
+		case f.Num == fieldAnyInt && f.Type == protowire.VarintType:
+			value = int64(f.Varint)
+		case f.Num == fieldAnyDouble && f.Type == protowire.Fixed64Type:
+			value = math.Float64frombits(f.Fixed)
+		case f.Num == fieldAnyBytes && f.Type == protowire.BytesType:
+			value = base64.StdEncoding.EncodeToString(f.Bytes)
+		case f.Num == fieldAnyArray && f.Type == protowire.BytesType:
+			values := []any{}
+			err := ingest.WalkProto(f.Bytes, func(f ingest.ProtoField) error {
+				if f.Num == fieldListValues && f.Type == protowire.BytesType {
+					v, err := decodeAnyValue(f.Bytes, depth+1)
+					if err != nil {
+						return err
+					}
+					values = append(values, v)
+				}
+				return nil
+			})
+			value = values
+			return err
+		case f.Num == fieldAnyKVList && f.Type == protowire.BytesType:
+			values := map[string]any{}
+			err := ingest.WalkProto(f.Bytes, func(f ingest.ProtoField) error {
+				if f.Num == fieldListValues && f.Type == protowire.BytesType {
+					kv, err := decodeKeyValue(f.Bytes, depth+1)
+					if err != nil {
+						return err
+					}
+					values[kv.Key] = kv.Value
+				}
+				return nil
+			})
+			value = values
+			return err
+		}
+		return nil
+	})
+	return value, err
+}
+
+// encodeProtobufResponse encodes an ExportLogsServiceResponse. The partial
+// success field is only set if records were rejected.
+func encodeProtobufResponse(rejected int64, message string) []byte {
+	if rejected == 0 && message == "" {
+		return []byte{}
+	}
+
+	var partial []byte
+	if rejected != 0 {
+		partial = protowire.AppendTag(partial, fieldPartialRejected, protowire.VarintType)
+		partial = protowire.AppendVarint(partial, uint64(rejected))
+	}
+	if message != "" {
+		partial = protowire.AppendTag(partial, fieldPartialErrorMessage, protowire.BytesType)
+		partial = protowire.AppendString(partial, message)
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, fieldResponsePartialSuccess, protowire.BytesType)
+	b = protowire.AppendBytes(b, partial)
+	return b
+}
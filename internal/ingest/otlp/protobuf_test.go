@@ -0,0 +1,94 @@
+package otlp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest/prototest"
+)
+
+func keyValue(key string, value []byte) []byte {
+	return prototest.Message(prototest.String(fieldKeyValueKey, key), prototest.Bytes(fieldKeyValueValue, value))
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	traceID := []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+
+	array := prototest.Message(
+		prototest.Bytes(fieldListValues, prototest.Message(prototest.Varint(fieldAnyInt, 7))),
+		prototest.Bytes(fieldListValues, prototest.Message(prototest.Fixed64(fieldAnyDouble, math.Float64bits(1.5)))),
+		prototest.Bytes(fieldListValues, prototest.Message(prototest.Bytes(fieldAnyBytes, []byte{0xff}))),
+	)
+	record := prototest.Message(
+		prototest.Fixed64(fieldRecordTime, 1700000000000000000),
+		prototest.Varint(fieldRecordSeverityNumber, 17),
+		prototest.String(fieldRecordSeverityText, "ERROR"),
+		prototest.Bytes(fieldRecordBody, prototest.Message(prototest.String(fieldAnyString, "payment failed"))),
+		prototest.Bytes(fieldRecordAttributes, keyValue("values", prototest.Message(prototest.Bytes(fieldAnyArray, array)))),
+		prototest.Bytes(fieldRecordAttributes, keyValue("ok", prototest.Message(prototest.Varint(fieldAnyBool, 1)))),
+		prototest.Bytes(fieldRecordTraceID, traceID),
+		prototest.Bytes(fieldRecordSpanID, make([]byte, 8)), // all zero: not set
+		prototest.String(fieldRecordEventName, "payment.failed"),
+		prototest.Varint(99, 1), // unknown fields are skipped
+	)
+	scopeLogs := prototest.Message(
+		prototest.Bytes(fieldScopeLogsScope, prototest.Message(prototest.String(fieldScopeName, "lib"))),
+		prototest.Bytes(fieldScopeLogsLogRecords, record),
+		prototest.Bytes(fieldScopeLogsLogRecords, prototest.Message(
+			prototest.Bytes(fieldRecordBody, prototest.Message(prototest.String(fieldAnyString, "short id"))),
+			prototest.Bytes(fieldRecordTraceID, []byte{1, 2, 3}),
+		)),
+	)
+	resource := prototest.Message(prototest.Bytes(fieldResourceAttributes, keyValue("service.name", prototest.Message(prototest.String(fieldAnyString, "billing")))))
+	body := prototest.Message(prototest.Bytes(fieldRequestResourceLogs, prototest.Message(
+		prototest.Bytes(fieldResourceLogsResource, resource),
+		prototest.Bytes(fieldResourceLogsScopeLogs, scopeLogs),
+	)))
+
+	req, err := DecodeProtobuf(body)
+	if err != nil {
+		t.Fatalf("DecodeProtobuf() error = %v", err)
+	}
+
+	entries, rejected, msg := req.Entries(time.Now())
+	if rejected != 1 || msg == "" {
+		t.Errorf("rejected = %d, message = %q, want the short trace ID rejected", rejected, msg)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	e := entries[0]
+	if e.Message != "payment failed" || e.Level != "error" || e.Service != "billing" {
+		t.Errorf("entry message %q, level %q, service %q", e.Message, e.Level, e.Service)
+	}
+	if e.TraceID != "5b8efff798038103d269b633813fc60c" || e.SpanID != "" {
+		t.Errorf("trace %q span %q", e.TraceID, e.SpanID)
+	}
+	wantAttrs := map[string]any{
+		"values":     []any{int64(7), 1.5, "/w=="},
+		"ok":         true,
+		"event_name": "payment.failed",
+		"scope":      map[string]any{"name": "lib"},
+	}
+	if !reflect.DeepEqual(e.Attributes, wantAttrs) {
+		t.Errorf("attributes = %#v\nwant %#v", e.Attributes, wantAttrs)
+	}
+}
+
+func TestDecodeProtobufDepthLimit(t *testing.T) {
+	value := prototest.Message(prototest.String(fieldAnyString, "leaf"))
+	for range maxValueDepth + 2 {
+		value = prototest.Message(prototest.Bytes(fieldAnyArray, prototest.Message(prototest.Bytes(fieldListValues, value))))
+	}
+	record := prototest.Message(prototest.Bytes(fieldRecordBody, value))
+	body := prototest.Message(prototest.Bytes(fieldRequestResourceLogs, prototest.Message(
+		prototest.Bytes(fieldResourceLogsScopeLogs, prototest.Message(prototest.Bytes(fieldScopeLogsLogRecords, record))),
+	)))
+
+	if _, err := DecodeProtobuf(body); err == nil {
+		t.Fatal("DecodeProtobuf() accepted values nested too deeply")
+	}
+}
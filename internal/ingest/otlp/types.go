@@ -0,0 +1,53 @@
+package otlp
+
+import "errors"
+
+// ErrInvalidPayload is returned for bodies that are not a valid
+// ExportLogsServiceRequest in the announced encoding.
+var ErrInvalidPayload = errors.New("invalid OTLP logs payload")
+
+// Content types of the two OTLP/HTTP encodings.
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// The types below hold the subset of ExportLogsServiceRequest that maps
+// onto log entries. Attribute and body values are already converted to
+// plain Go values: string, bool, int64, float64, []any or map[string]any
+// (bytes become base64 strings).
+
+// Request is a decoded ExportLogsServiceRequest.
+type Request struct {
+	ResourceLogs []ResourceLogs
+}
+
+type ResourceLogs struct {
+	Resource  []KeyValue
+	ScopeLogs []ScopeLogs
+}
+
+type ScopeLogs struct {
+	ScopeName    string
+	ScopeVersion string
+	LogRecords   []LogRecord
+}
+
+type LogRecord struct {
+	TimeUnixNano         uint64
+	ObservedTimeUnixNano uint64
+	SeverityNumber       int
+	SeverityText         string
+	Body                 any
+	Attributes           []KeyValue
+	TraceID              []byte
+	SpanID               []byte
+	EventName            string
+
+	err error // rejects the record, e.g. a malformed ID in OTLP/JSON
+}
+
+type KeyValue struct {
+	Key   string
+	Value any
+}
@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrPayloadTooLarge is returned when a decoded payload exceeds its limit.
+	ErrPayloadTooLarge = errors.New("payload too large")
+	// ErrUnsupportedEncoding is returned for unknown Content-Encoding values.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// ReadPayload reads a request body, decompressing it according to its
+// Content-Encoding (identity, gzip or deflate). The limit applies to the
+// decoded size, so small compressed bodies cannot expand without bound.
+func ReadPayload(r io.Reader, contentEncoding string, limit int) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "deflate":
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deflate body: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedEncoding, contentEncoding)
+	}
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if n > int64(limit) {
+		return nil, ErrPayloadTooLarge
+	}
+	return buf.Bytes(), nil
+}
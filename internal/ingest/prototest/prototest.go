@@ -0,0 +1,47 @@
+// Package prototest builds protobuf messages field by field for the tests
+// of the protobuf receivers (OTLP, Loki), which decode the wire format
+// with ingest.WalkProto instead of generated code.
+package prototest
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// Field appends one encoded field to a message.
+type Field func([]byte) []byte
+
+// Message encodes fields in order.
+func Message(fields ...Field) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = f(b)
+	}
+	return b
+}
+
+// Bytes is a length-delimited field: bytes or an embedded message.
+func Bytes(num protowire.Number, v []byte) Field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, v)
+	}
+}
+
+// String is a string field.
+func String(num protowire.Number, v string) Field {
+	return Bytes(num, []byte(v))
+}
+
+// Varint is a varint field.
+func Varint(num protowire.Number, v uint64) Field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+}
+
+// Fixed64 is a fixed64 field, e.g. a double or fixed64 timestamp.
+func Fixed64(num protowire.Number, v uint64) Field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, v)
+	}
+}
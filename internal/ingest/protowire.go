@@ -0,0 +1,54 @@
+package ingest
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// ProtoField is one decoded protobuf field. Only the member matching Type
+// is set.
+type ProtoField struct {
+	Num    protowire.Number
+	Type   protowire.Type
+	Varint uint64
+	Fixed  uint64 // fixed64 and fixed32 values
+	Bytes  []byte
+}
+
+// WalkProto calls fn for every field of the protobuf message b, in wire
+// order. Groups are skipped. Decoders of the push protocols (OTLP, Loki)
+// use it instead of generated code.
+func WalkProto(b []byte, fn func(f ProtoField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := ProtoField{Num: num, Type: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.Varint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.Fixed, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.Fixed = uint64(v)
+		case protowire.BytesType:
+			f.Bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if typ == protowire.StartGroupType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
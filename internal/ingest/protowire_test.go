@@ -0,0 +1,55 @@
+package ingest
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWalkProto(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 300)
+	b = protowire.AppendTag(b, 2, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 7)
+	b = protowire.AppendTag(b, 3, protowire.StartGroupType)
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, 3, protowire.EndGroupType)
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, 9)
+	b = protowire.AppendTag(b, 5, protowire.BytesType)
+	b = protowire.AppendString(b, "hi")
+
+	var got []ProtoField
+	if err := WalkProto(b, func(f ProtoField) error {
+		got = append(got, f)
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkProto() error = %v", err)
+	}
+
+	want := []ProtoField{
+		{Num: 1, Type: protowire.VarintType, Varint: 300},
+		{Num: 2, Type: protowire.Fixed32Type, Fixed: 7},
+		{Num: 4, Type: protowire.Fixed64Type, Fixed: 9},
+		{Num: 5, Type: protowire.BytesType, Bytes: []byte("hi")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkProto() fields = %+v, want %+v", got, want)
+	}
+}
+
+func TestWalkProtoTruncated(t *testing.T) {
+	for _, b := range [][]byte{
+		{0x08},             // varint without value
+		{0x2a, 0x05, 'h'},  // bytes shorter than their length
+		{0x11, 0x01, 0x02}, // fixed64 cut short
+		{0x80},             // incomplete tag
+	} {
+		if err := WalkProto(b, func(ProtoField) error { return nil }); err == nil {
+			t.Errorf("WalkProto(%x) accepted a truncated message", b)
+		}
+	}
+}
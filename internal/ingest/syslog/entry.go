@@ -0,0 +1,73 @@
+package syslog
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// facilityNames are the conventional facility keywords by code.
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// FacilityName returns the keyword of a facility code, e.g. "daemon".
+func FacilityName(code int) string {
+	if code >= 0 && code < len(facilityNames) {
+		return facilityNames[code]
+	}
+	return strconv.Itoa(code)
+}
+
+// Entry maps m onto a log entry: the app name becomes the service, the
+// hostname the host, and the remaining syslog fields are kept under the
+// "syslog" attribute. received stands in for a missing timestamp. NUL
+// characters are stripped.
+func (m Message) Entry(received time.Time, remoteAddr string) *model.LogEntry {
+	attrs := map[string]any{
+		"facility": FacilityName(m.Facility),
+		"severity": m.Severity,
+		"format":   string(m.Format),
+	}
+	if m.ProcID != "" {
+		attrs["procid"] = m.ProcID
+	}
+	if m.MsgID != "" {
+		attrs["msgid"] = m.MsgID
+	}
+	if len(m.StructuredData) > 0 {
+		sd := make(map[string]any, len(m.StructuredData))
+		for id, params := range m.StructuredData {
+			p := make(map[string]any, len(params))
+			for k, v := range params {
+				p[k] = v
+			}
+			sd[id] = p
+		}
+		attrs["structured_data"] = sd
+	}
+	if remoteAddr != "" {
+		attrs["remote_addr"] = remoteAddr
+	}
+
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = received
+	}
+
+	entry := &model.LogEntry{
+		Message:    m.Message,
+		Timestamp:  ts.UTC(),
+		Attributes: map[string]any{"syslog": attrs},
+		Service:    m.AppName,
+		Host:       m.Hostname,
+	}
+	entry.SetSeverity(model.SeverityFromSyslog(m.Severity))
+	ingest.StripNUL(entry)
+
+	return entry
+}
@@ -0,0 +1,274 @@
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format identifies the syslog protocol version of a message.
+type Format string
+
+const (
+	FormatRFC5424 Format = "rfc5424"
+	FormatRFC3164 Format = "rfc3164"
+)
+
+var (
+	// ErrInvalidFrame is returned for frames that are not syslog messages.
+	ErrInvalidFrame = errors.New("invalid syslog frame")
+	// ErrEmptyMessage is returned for messages without text (an empty or
+	// NILVALUE MSG), which cannot be stored as log entries.
+	ErrEmptyMessage = errors.New("empty syslog message")
+)
+
+// defaultPriority is user.notice, which RFC 3164 assigns to messages
+// without a valid PRI part.
+const defaultPriority = 13
+
+// Message is a parsed syslog message. Absent fields are empty.
+type Message struct {
+	Format         Format
+	Facility       int
+	Severity       int
+	Timestamp      time.Time // zero if the sender gave none
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+// Parse parses an RFC 5424 or RFC 3164 message. now resolves the missing
+// year of RFC 3164 timestamps. Frames without a PRI part are accepted as
+// user.notice messages carrying the whole frame as text. Messages without
+// text yield ErrEmptyMessage.
+func Parse(frame []byte, now time.Time) (Message, error) {
+	frame = bytes.TrimRight(frame, "\r\n\x00")
+	if len(frame) == 0 {
+		return Message{}, ErrInvalidFrame
+	}
+	if !utf8.Valid(frame) {
+		frame = bytes.ToValidUTF8(frame, []byte("\uFFFD"))
+	}
+
+	pri, rest, ok := parsePRI(string(frame))
+	if !ok {
+		pri, rest = defaultPriority, string(frame)
+	}
+	m := Message{Facility: pri / 8, Severity: pri % 8}
+
+	if strings.HasPrefix(rest, "1 ") {
+		if err := m.parse5424(rest[2:]); err != nil {
+			return Message{}, err
+		}
+	} else {
+		m.parse3164(rest, now)
+	}
+
+	if strings.TrimSpace(m.Message) == "" {
+		return Message{}, ErrEmptyMessage
+	}
+	return m, nil
+}
+
+// parsePRI reads "<N>" with 0 <= N <= 191 from the start of s.
+func parsePRI(s string) (int, string, bool) {
+	if len(s) < 3 || s[0] != '<' {
+		return 0, s, false
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, s, false
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, s, false
+	}
+	return pri, s[end+1:], true
+}
+
+// parse5424 parses the part after "<PRI>1 ":
+// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func (m *Message) parse5424(s string) error {
+	m.Format = FormatRFC5424
+
+	var fields [5]string
+	for i := range fields {
+		var ok bool
+		fields[i], s, ok = strings.Cut(s, " ")
+		if !ok || fields[i] == "" {
+			return ErrInvalidFrame
+		}
+		if fields[i] == "-" {
+			fields[i] = ""
+		}
+	}
+
+	if fields[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return ErrInvalidFrame
+		}
+		m.Timestamp = ts
+	}
+	m.Hostname, m.AppName, m.ProcID, m.MsgID = fields[1], fields[2], fields[3], fields[4]
+
+	sd, rest, err := parseStructuredData(s)
+	if err != nil {
+		return err
+	}
+	m.StructuredData = sd
+
+	if strings.HasPrefix(rest, " ") {
+		m.Message = strings.TrimPrefix(rest[1:], "\uFEFF")
+		if m.Message == "-" {
+			m.Message = "" // NILVALUE, sent by some relays for an absent MSG
+		}
+	} else if rest != "" {
+		return ErrInvalidFrame
+	}
+	return nil
+}
+
+// parseStructuredData parses "-" or one or more [SD-ID name="value" ...]
+// elements and returns the remainder of s.
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if strings.HasPrefix(s, "-") {
+		return nil, s[1:], nil
+	}
+	if !strings.HasPrefix(s, "[") {
+		return nil, s, ErrInvalidFrame
+	}
+
+	sd := map[string]map[string]string{}
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return nil, s, ErrInvalidFrame
+		}
+		id := s[:end]
+		params := map[string]string{}
+		s = s[end:]
+
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			eq := strings.IndexByte(s, '=')
+			if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+				return nil, s, ErrInvalidFrame
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			// The value ends at the first unescaped quote; \" \\ and \]
+			// are escapes, any other backslash is literal.
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+					value.WriteByte(s[i+1])
+					i++
+					continue
+				}
+				if c == '"' {
+					s = s[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return nil, s, ErrInvalidFrame
+			}
+			params[name] = value.String()
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, s, ErrInvalidFrame
+		}
+		s = s[1:]
+		sd[id] = params
+	}
+
+	return sd, s, nil
+}
+
+// parse3164 parses the part after "<PRI>": [TIMESTAMP HOSTNAME] TAG: MSG.
+// BSD syslog is loosely specified, so anything unexpected ends up in the
+// message rather than being rejected.
+func (m *Message) parse3164(s string, now time.Time) {
+	m.Format = FormatRFC3164
+
+	if ts, rest, ok := parse3164Timestamp(s, now); ok {
+		m.Timestamp = ts
+		s = rest
+		// A hostname follows a timestamp unless the token is already the tag.
+		if token, rest, ok := strings.Cut(s, " "); ok && token != "" && !strings.ContainsAny(token, ":[") {
+			m.Hostname = token
+			s = rest
+		}
+	}
+
+	m.AppName, m.ProcID, m.Message = parseTag(s)
+}
+
+func parse3164Timestamp(s string, now time.Time) (time.Time, string, bool) {
+	// "Jan  2 15:04:05 " has a fixed width.
+	if len(s) > len(time.Stamp) && s[len(time.Stamp)] == ' ' {
+		if ts, err := time.ParseInLocation(time.Stamp, s[:len(time.Stamp)], time.UTC); err == nil {
+			// No year: assume the current one unless that lies in the future.
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			return ts, s[len(time.Stamp)+1:], true
+		}
+	}
+
+	// Some daemons send RFC 3339 timestamps in BSD format messages.
+	if token, rest, ok := strings.Cut(s, " "); ok {
+		if ts, err := time.Parse(time.RFC3339Nano, token); err == nil {
+			return ts, rest, true
+		}
+	}
+
+	return time.Time{}, s, false
+}
+
+// parseTag splits "tag[pid]: message" or "tag: message". Without a valid
+// tag the whole string is the message.
+func parseTag(s string) (tag, pid, msg string) {
+	end := 0
+	for end < len(s) && end <= 48 && isTagChar(s[end]) {
+		end++
+	}
+	if end == 0 || end >= len(s) {
+		return "", "", s
+	}
+
+	tag, rest := s[:end], s[end:]
+	if strings.HasPrefix(rest, "[") {
+		pidEnd := strings.IndexByte(rest, ']')
+		if pidEnd < 0 {
+			return "", "", s
+		}
+		pid, rest = rest[1:pidEnd], rest[pidEnd+1:]
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", s
+	}
+	return tag, pid, strings.TrimPrefix(rest[1:], " ")
+}
+
+// isTagChar reports whether c may appear in a tag. RFC 3164 allows only
+// alphanumerics, but daemons commonly use these as well.
+func isTagChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '/'
+}
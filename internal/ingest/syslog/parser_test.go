@@ -0,0 +1,246 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		frame string
+		want  Message
+	}{
+		{
+			name:  "rfc5424",
+			frame: "<34>1 2024-03-10T11:59:58.123Z web01 api 4711 ID47 - user logged in\n",
+			want: Message{
+				Format: FormatRFC5424, Facility: 4, Severity: 2,
+				Timestamp: time.Date(2024, time.March, 10, 11, 59, 58, 123000000, time.UTC),
+				Hostname:  "web01", AppName: "api", ProcID: "4711", MsgID: "ID47",
+				Message: "user logged in",
+			},
+		},
+		{
+			name:  "rfc5424 nil values and BOM",
+			frame: "<14>1 - - - - - - \xef\xbb\xbfhello",
+			want:  Message{Format: FormatRFC5424, Facility: 1, Severity: 6, Message: "hello"},
+		},
+		{
+			name:  "rfc5424 structured data escapes",
+			frame: `<165>1 - h a - - [exampleSDID@32473 iut="3" eventSource="App\"lication\]" path="C:\\tmp\x"][meta sequenceId="1"] msg`,
+			want: Message{
+				Format: FormatRFC5424, Facility: 20, Severity: 5,
+				Hostname: "h", AppName: "a",
+				StructuredData: map[string]map[string]string{
+					"exampleSDID@32473": {"iut": "3", "eventSource": `App"lication]`, "path": `C:\tmp\x`},
+					"meta":              {"sequenceId": "1"},
+				},
+				Message: "msg",
+			},
+		},
+		{
+			name:  "rfc5424 structured data without params",
+			frame: "<14>1 - h a - - [origin] msg",
+			want: Message{
+				Format: FormatRFC5424, Facility: 1, Severity: 6, Hostname: "h", AppName: "a",
+				StructuredData: map[string]map[string]string{"origin": {}},
+				Message:        "msg",
+			},
+		},
+		{
+			name:  "rfc3164",
+			frame: "<13>Mar  9 08:15:00 mail postfix/smtpd[123]: connect from unknown",
+			want: Message{
+				Format: FormatRFC3164, Facility: 1, Severity: 5,
+				Timestamp: time.Date(2024, time.March, 9, 8, 15, 0, 0, time.UTC),
+				Hostname:  "mail", AppName: "postfix/smtpd", ProcID: "123",
+				Message: "connect from unknown",
+			},
+		},
+		{
+			name:  "rfc3164 timestamp in the future is last year",
+			frame: "<13>Dec 31 23:00:00 host app: late",
+			want: Message{
+				Format: FormatRFC3164, Facility: 1, Severity: 5,
+				Timestamp: time.Date(2023, time.December, 31, 23, 0, 0, 0, time.UTC),
+				Hostname:  "host", AppName: "app", Message: "late",
+			},
+		},
+		{
+			name:  "rfc3164 without timestamp",
+			frame: "<11>su: auth failure",
+			want:  Message{Format: FormatRFC3164, Facility: 1, Severity: 3, AppName: "su", Message: "auth failure"},
+		},
+		{
+			name:  "no PRI",
+			frame: "plain text line\r\n",
+			want:  Message{Format: FormatRFC3164, Facility: 1, Severity: 5, Message: "plain text line"},
+		},
+		{
+			name:  "PRI out of range",
+			frame: "<192>hello",
+			want:  Message{Format: FormatRFC3164, Facility: 1, Severity: 5, Message: "<192>hello"},
+		},
+		{
+			name:  "invalid UTF-8",
+			frame: "<14>msg \xff",
+			want:  Message{Format: FormatRFC3164, Facility: 1, Severity: 6, Message: "msg \uFFFD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.frame), now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"empty", "\r\n"},
+		{"rfc5424 missing fields", "<14>1 - host app"},
+		{"rfc5424 bad timestamp", "<14>1 yesterday h a - - - msg"},
+		{"rfc5424 bad structured data", "<14>1 - h a - - nope msg"},
+		{"rfc5424 unterminated param", `<14>1 - h a - - [id k="v] msg`},
+		{"rfc5424 param without quotes", "<14>1 - h a - - [id k=v] msg"},
+		{"rfc5424 unclosed element", `<14>1 - h a - - [id k="v"`},
+		{"rfc5424 no space before message", `<14>1 - h a - - [id k="v"]msg`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.frame), time.Now()); !errors.Is(err, ErrInvalidFrame) {
+				t.Errorf("Parse() error = %v, want ErrInvalidFrame", err)
+			}
+		})
+	}
+}
+
+func TestParseEmptyMessage(t *testing.T) {
+	for _, frame := range []string{
+		"<14>1 - host app - - -",
+		"<14>1 - host app - - - ",
+		"<14>1 - host app - - - -",
+		"<14>1 - host app - - [origin] \xef\xbb\xbf  ",
+		"<13>Mar  9 08:15:00 host app:",
+		"<13>Mar  9 08:15:00 host app[1]:   ",
+		"<13>",
+	} {
+		if _, err := Parse([]byte(frame), time.Now()); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("Parse(%q) error = %v, want ErrEmptyMessage", frame, err)
+		}
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		maxBytes int
+		want     []string
+		wantErr  []error // per frame, nil for a frame read
+	}{
+		{
+			name:     "octet counted",
+			stream:   "11 <14>1 - - -13 <13>hello\nyou",
+			maxBytes: 100,
+			want:     []string{"<14>1 - - -", "<13>hello\nyou"},
+			wantErr:  []error{nil, nil},
+		},
+		{
+			name:     "newline terminated",
+			stream:   "<14>one\n<14>two\n",
+			maxBytes: 100,
+			want:     []string{"<14>one\n", "<14>two\n"},
+			wantErr:  []error{nil, nil},
+		},
+		{
+			name:     "mixed framing",
+			stream:   "5 <14>a<14>b\n",
+			maxBytes: 100,
+			want:     []string{"<14>a", "<14>b\n"},
+			wantErr:  []error{nil, nil},
+		},
+		{
+			name:     "octet count too large is skipped",
+			stream:   "12 <14>toolong!4 <1>x",
+			maxBytes: 8,
+			want:     []string{"", "<1>x"},
+			wantErr:  []error{errFrameTooLarge, nil},
+		},
+		{
+			name:     "line too large is skipped",
+			stream:   "<14>" + strings.Repeat("x", 100) + "\n<14>ok\n",
+			maxBytes: 16,
+			want:     []string{"", "<14>ok\n"},
+			wantErr:  []error{errFrameTooLarge, nil},
+		},
+		{
+			name:     "digits without space are a line",
+			stream:   "2024-03-10 started\n0 zero\n1234567890 long\n12\n",
+			maxBytes: 100,
+			want:     []string{"2024-03-10 started\n", "0 zero\n", "1234567890 long\n", "12\n"},
+			wantErr:  []error{nil, nil, nil, nil},
+		},
+		{
+			name:     "truncated octet counted frame",
+			stream:   "10 <14>",
+			maxBytes: 100,
+			want:     []string{""},
+			wantErr:  []error{io.ErrUnexpectedEOF},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReaderSize(strings.NewReader(tt.stream), 16)
+			for i, want := range tt.want {
+				got, err := readFrame(r, tt.maxBytes)
+				if !errors.Is(err, tt.wantErr[i]) {
+					t.Fatalf("frame %d: error = %v, want %v", i, err, tt.wantErr[i])
+				}
+				if string(got) != want {
+					t.Errorf("frame %d = %q, want %q", i, got, want)
+				}
+			}
+			if _, err := readFrame(r, tt.maxBytes); err == nil {
+				t.Errorf("expected no further frames")
+			}
+		})
+	}
+}
+
+func TestEntryStripsNUL(t *testing.T) {
+	m := Message{
+		Format:         FormatRFC5424,
+		Severity:       3,
+		AppName:        "a\x00pp",
+		Message:        "hello\x00world",
+		StructuredData: map[string]map[string]string{"id": {"k\x00": "v\x00"}},
+	}
+	e := m.Entry(time.Now(), "")
+	if e.Message != "helloworld" || e.Service != "app" {
+		t.Errorf("Entry() message %q, service %q still contain NUL", e.Message, e.Service)
+	}
+	sd := e.Attributes["syslog"].(map[string]any)["structured_data"].(map[string]any)
+	if got := sd["id"].(map[string]any)["k"]; got != "v" {
+		t.Errorf("structured data param = %q, want %q", got, "v")
+	}
+}
@@ -0,0 +1,243 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	syslogcfg "github.com/julian-richter/ApiTemplate/internal/config/syslog"
+	"github.com/julian-richter/ApiTemplate/internal/ingest"
+)
+
+// errFrameTooLarge is returned by readFrame for frames over the size limit.
+// The frame is skipped and reading can continue.
+var errFrameTooLarge = errors.New("syslog frame too large")
+
+// Server receives syslog messages over UDP and TCP and queues them on a
+// batcher.
+type Server struct {
+	cfg     syslogcfg.Config
+	batcher *ingest.Batcher
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewServer creates a syslog server writing to batcher.
+func NewServer(cfg config.Config, batcher *ingest.Batcher) *Server {
+	return &Server{
+		cfg:     cfg.Syslog,
+		batcher: batcher,
+		conns:   map[net.Conn]struct{}{},
+	}
+}
+
+// Start binds the configured listeners and serves them in the background
+// until ctx is cancelled. It fails if a listener cannot be bound.
+func (s *Server) Start(ctx context.Context) error {
+	var udp net.PacketConn
+	if s.cfg.UDPAddr != "" {
+		var err error
+		udp, err = net.ListenPacket("udp", s.cfg.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("syslog: listen udp %s: %w", s.cfg.UDPAddr, err)
+		}
+	}
+
+	var tcp net.Listener
+	if s.cfg.TCPAddr != "" {
+		var err error
+		tcp, err = net.Listen("tcp", s.cfg.TCPAddr)
+		if err != nil {
+			if udp != nil {
+				_ = udp.Close()
+			}
+			return fmt.Errorf("syslog: listen tcp %s: %w", s.cfg.TCPAddr, err)
+		}
+	}
+
+	if udp != nil {
+		go s.serveUDP(ctx, udp)
+	}
+	if tcp != nil {
+		go s.serveTCP(ctx, tcp)
+	}
+
+	go func() {
+		<-ctx.Done()
+		if udp != nil {
+			_ = udp.Close()
+		}
+		if tcp != nil {
+			_ = tcp.Close()
+		}
+		s.mu.Lock()
+		for conn := range s.conns {
+			_ = conn.Close()
+		}
+		s.mu.Unlock()
+	}()
+
+	return nil
+}
+
+// serveUDP handles one message per datagram.
+func (s *Server) serveUDP(ctx context.Context, conn net.PacketConn) {
+	buf := make([]byte, s.cfg.MaxMessageBytes)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[warning] syslog: udp read failed: %v", err)
+				continue
+			}
+			return
+		}
+		s.handle(ctx, buf[:n], addr.String())
+	}
+}
+
+func (s *Server) serveTCP(ctx context.Context, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[warning] syslog: tcp accept failed: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.serveConn(ctx, conn)
+	}
+}
+
+// serveConn reads frames until the peer disconnects or stays idle too long.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	remote := conn.RemoteAddr().String()
+	r := bufio.NewReaderSize(conn, 64*1024)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.cfg.IdleTimeout)); err != nil {
+			return
+		}
+
+		frame, err := readFrame(r, s.cfg.MaxMessageBytes)
+		if errors.Is(err, errFrameTooLarge) {
+			log.Printf("[warning] syslog: skipped frame over %d bytes from %s", s.cfg.MaxMessageBytes, remote)
+			continue
+		}
+		if len(frame) > 0 {
+			s.handle(ctx, frame, remote)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				log.Printf("[warning] syslog: closing connection from %s: %v", remote, err)
+			}
+			return
+		}
+	}
+}
+
+// maxOctetCountDigits bounds the length prefix of octet-counted frames.
+// Longer digit runs are taken as the start of a non-transparent frame.
+const maxOctetCountDigits = 9
+
+// octetCount consumes the "LEN SP" prefix of an octet-counted frame and
+// returns LEN. If the frame does not start with one, nothing is consumed.
+func octetCount(r *bufio.Reader) (int, bool) {
+	n := 0
+	for i := 1; i <= maxOctetCountDigits+1; i++ {
+		b, err := r.Peek(i)
+		if err != nil {
+			// The frame ends within the prefix, it is read as a line.
+			return 0, false
+		}
+		switch c := b[i-1]; {
+		case c == ' ' && i > 1:
+			_, _ = r.Discard(i)
+			return n, true
+		case c >= '0' && c <= '9' && i <= maxOctetCountDigits && (i > 1 || c != '0'):
+			n = n*10 + int(c-'0')
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// handle parses one frame and queues the entry.
+func (s *Server) handle(ctx context.Context, frame []byte, remote string) {
+	now := time.Now().UTC()
+	msg, err := Parse(frame, now)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFrame) && len(bytes.TrimSpace(frame)) > 0 {
+			// Keep malformed RFC 5424 frames as plain text rather than losing them.
+			msg = Message{Facility: defaultPriority / 8, Severity: defaultPriority % 8, Message: string(frame)}
+		} else {
+			return
+		}
+	}
+	_ = s.batcher.Add(ctx, msg.Entry(now, remote))
+}
+
+// readFrame reads one TCP frame: octet-counted ("LEN SP MSG", RFC 6587) if
+// it starts with a length followed by a space, otherwise terminated by LF.
+func readFrame(r *bufio.Reader, maxBytes int) ([]byte, error) {
+	if _, err := r.Peek(1); err != nil {
+		return nil, err
+	}
+
+	if n, ok := octetCount(r); ok {
+		if n > maxBytes {
+			if _, err := r.Discard(n); err != nil {
+				return nil, err
+			}
+			return nil, errFrameTooLarge
+		}
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	var frame []byte
+	tooLarge := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !tooLarge {
+			if len(frame)+len(chunk) > maxBytes+1 {
+				tooLarge, frame = true, nil
+			} else {
+				frame = append(frame, chunk...)
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if tooLarge && err == nil {
+			return nil, errFrameTooLarge
+		}
+		return frame, err
+	}
+}
@@ -0,0 +1,47 @@
+package ingest
+
+import (
+	"strings"
+
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// StripNUL removes NUL characters from the strings of entry, attribute keys
+// and nested values included. Postgres stores neither in text nor in jsonb,
+// so a single NUL would otherwise fail the whole batch.
+func StripNUL(entry *model.LogEntry) {
+	for _, s := range []*string{
+		&entry.Message, &entry.Service, &entry.Host, &entry.Environment, &entry.Version,
+	} {
+		*s = stripNUL(*s)
+	}
+	if entry.Attributes != nil {
+		entry.Attributes = stripNULValue(entry.Attributes).(map[string]any)
+	}
+}
+
+func stripNUL(s string) string {
+	if !strings.Contains(s, "\x00") {
+		return s
+	}
+	return strings.ReplaceAll(s, "\x00", "")
+}
+
+func stripNULValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return stripNUL(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[stripNUL(k)] = stripNULValue(val)
+		}
+		return out
+	case []any:
+		for i, val := range v {
+			v[i] = stripNULValue(val)
+		}
+		return v
+	}
+	return v
+}
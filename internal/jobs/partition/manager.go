@@ -0,0 +1,541 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	partitioncfg "github.com/julian-richter/ApiTemplate/internal/config/partition"
+)
+
+// ErrNotPartitioned is returned when the table is a plain heap table, i.e.
+// Partitioning_migration.sql has not been applied.
+var ErrNotPartitioned = errors.New("table is not partitioned")
+
+// Partition is one child table of the partitioned log table.
+type Partition struct {
+	Name      string
+	From      time.Time // inclusive lower bound, zero for the default partition
+	To        time.Time // exclusive upper bound, zero for the default partition
+	IsDefault bool
+}
+
+// Range is a half-open time range [From, To).
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Manager keeps time partitions of the log table ahead of the clock.
+type Manager struct {
+	pool          *pgxpool.Pool
+	table         string
+	interval      partitioncfg.Interval
+	premake       int
+	checkInterval time.Duration
+}
+
+// NewManager creates a partition manager for the log_entries table.
+func NewManager(pool *pgxpool.Pool, cfg config.Config) *Manager {
+	return &Manager{
+		pool:          pool,
+		table:         "log_entries",
+		interval:      cfg.Partition.Interval,
+		premake:       cfg.Partition.Premake,
+		checkInterval: cfg.Partition.CheckInterval,
+	}
+}
+
+// Run checks the partitions immediately and then every check interval until
+// ctx is cancelled. It gives up if the table is not partitioned.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx); err != nil {
+			if errors.Is(err, ErrNotPartitioned) {
+				log.Printf("[warning] partition manager stopped: %s %v", m.table, err)
+				return
+			}
+			log.Printf("[warning] partition check failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check creates missing future partitions and logs gaps in coverage as
+// well as rows that ended up in the default partition.
+func (m *Manager) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	// Failed periods are logged by EnsureFuture; the checks below still run.
+	created, ensureErr := m.EnsureFuture(ctx, time.Now())
+	for _, name := range created {
+		log.Printf("[info] created partition %s", name)
+	}
+	if errors.Is(ensureErr, ErrNotPartitioned) {
+		return ensureErr
+	}
+
+	missing, err := m.Missing(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, r := range missing {
+		log.Printf("[warning] no partition covers %s - %s, rows go to the default partition",
+			r.From.Format(time.RFC3339), r.To.Format(time.RFC3339))
+	}
+
+	n, err := m.DefaultRows(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[warning] %d rows in the default partition of %s", n, m.table)
+	}
+
+	return ensureErr
+}
+
+// partitionBoundPattern extracts the bounds from pg_get_expr(relpartbound).
+var partitionBoundPattern = regexp.MustCompile(`FROM \('([^']+)'\) TO \('([^']+)'\)`)
+
+// Partitions lists the partitions of the table ordered by lower bound,
+// with the default partition (if any) last.
+func (m *Manager) Partitions(ctx context.Context) ([]Partition, error) {
+	var kind string
+	err := m.pool.QueryRow(ctx,
+		`SELECT c.relkind::text FROM pg_class c WHERE c.oid = to_regclass($1)`, m.table,
+	).Scan(&kind)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("partition: table %s not found", m.table)
+		}
+		return nil, fmt.Errorf("partition: relkind lookup failed: %w", err)
+	}
+	if kind != "p" {
+		return nil, ErrNotPartitioned
+	}
+
+	// Bounds are rendered in UTC so they parse consistently.
+	tx, err := m.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("partition: begin failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SET LOCAL TimeZone = 'UTC'`); err != nil {
+		return nil, fmt.Errorf("partition: set time zone failed: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT child.relname, pg_get_expr(child.relpartbound, child.oid)
+		FROM pg_inherits i
+		JOIN pg_class child ON child.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)`, m.table)
+	if err != nil {
+		return nil, fmt.Errorf("partition: list failed: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Partition
+	for rows.Next() {
+		var name, bound string
+		if err := rows.Scan(&name, &bound); err != nil {
+			return nil, fmt.Errorf("partition: row scan error: %w", err)
+		}
+
+		if bound == "DEFAULT" {
+			result = append(result, Partition{Name: name, IsDefault: true})
+			continue
+		}
+
+		match := partitionBoundPattern.FindStringSubmatch(bound)
+		if match == nil {
+			return nil, fmt.Errorf("partition: unexpected bound for %s: %s", name, bound)
+		}
+		from, err := parseBound(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("partition: bound of %s: %w", name, err)
+		}
+		to, err := parseBound(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("partition: bound of %s: %w", name, err)
+		}
+		result = append(result, Partition{Name: name, From: from, To: to})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("partition: rows error: %w", err)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IsDefault != result[j].IsDefault {
+			return !result[i].IsDefault
+		}
+		return result[i].From.Before(result[j].From)
+	})
+
+	return result, nil
+}
+
+// parseBound parses a timestamptz literal as printed by Postgres in UTC.
+func parseBound(s string) (time.Time, error) {
+	for _, layout := range []string{
+		"2006-01-02 15:04:05-07",
+		"2006-01-02 15:04:05.999999-07",
+		"2006-01-02 15:04:05-07:00",
+		"2006-01-02 15:04:05.999999-07:00",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse bound %q", s)
+}
+
+// periodStart truncates t to the start of its partition period in UTC.
+func (m *Manager) periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	if m.interval == partitioncfg.IntervalDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// nextPeriod returns the start of the period after the one starting at t.
+func (m *Manager) nextPeriod(t time.Time) time.Time {
+	if m.interval == partitioncfg.IntervalDaily {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// partitionName returns e.g. log_entries_p20260117 or log_entries_p202601.
+func (m *Manager) partitionName(from time.Time) string {
+	if m.interval == partitioncfg.IntervalDaily {
+		return fmt.Sprintf("%s_p%s", m.table, from.Format("20060102"))
+	}
+	return fmt.Sprintf("%s_p%s", m.table, from.Format("200601"))
+}
+
+// EnsureFuture creates the partitions for the current period and the next
+// premake periods. Periods that overlap an existing partition, e.g. one
+// created with another interval, are skipped. A period that cannot be
+// created is logged and the remaining ones are still attempted; the
+// failures are returned joined. It returns the created names.
+func (m *Manager) EnsureFuture(ctx context.Context, now time.Time) ([]string, error) {
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultPartition string
+	for _, p := range existing {
+		if p.IsDefault {
+			defaultPartition = p.Name
+		}
+	}
+
+	var created []string
+	var errs []error
+	from := m.periodStart(now)
+	for i := 0; i <= m.premake; i++ {
+		to := m.nextPeriod(from)
+		if !overlaps(existing, from, to) {
+			name := m.partitionName(from)
+			if err := m.create(ctx, name, defaultPartition, from, to); err != nil {
+				log.Printf("[warning] %v", err)
+				errs = append(errs, err)
+			} else {
+				created = append(created, name)
+			}
+		}
+		from = to
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// create adds the partition for [from, to). CREATE TABLE ... PARTITION OF
+// fails if the default partition already holds rows of that range, e.g.
+// entries with future timestamps. Those rows are then moved: the table is
+// created standalone, the rows are moved into it and it is attached, all
+// in one transaction.
+func (m *Manager) create(ctx context.Context, name, defaultPartition string, from, to time.Time) error {
+	// DDL cannot take bind parameters; the bounds are formatted by us.
+	table := pgx.Identifier{name}.Sanitize()
+	parent := pgx.Identifier{m.table}.Sanitize()
+	bounds := fmt.Sprintf(`FROM ('%s') TO ('%s')`, from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	stranded := false
+	if defaultPartition != "" {
+		query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE timestamp >= $1 AND timestamp < $2)`,
+			pgx.Identifier{defaultPartition}.Sanitize())
+		if err := m.pool.QueryRow(ctx, query, from, to).Scan(&stranded); err != nil {
+			return fmt.Errorf("partition: check default partition for %s failed: %w", name, err)
+		}
+	}
+
+	if !stranded {
+		stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES %s`, table, parent, bounds)
+		if _, err := m.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("partition: create %s failed: %w", name, err)
+		}
+		return nil
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("partition: begin failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Generated columns (search_vector) are computed, not copied.
+	var columns string
+	err = tx.QueryRow(ctx, `
+		SELECT string_agg(quote_ident(attname), ', ' ORDER BY attnum)
+		FROM pg_attribute
+		WHERE attrelid = to_regclass($1) AND attnum > 0 AND NOT attisdropped AND attgenerated = ''`,
+		m.table,
+	).Scan(&columns)
+	if err != nil {
+		return fmt.Errorf("partition: list columns of %s failed: %w", m.table, err)
+	}
+
+	// The check constraint lets ATTACH skip its validation scan.
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING GENERATED)`, table, parent),
+		fmt.Sprintf(`ALTER TABLE %s ADD CONSTRAINT %s CHECK (timestamp >= '%s' AND timestamp < '%s')`,
+			table, pgx.Identifier{name + "_bounds"}.Sanitize(), from.Format(time.RFC3339), to.Format(time.RFC3339)),
+		fmt.Sprintf(`WITH moved AS (DELETE FROM %s WHERE timestamp >= '%s' AND timestamp < '%s' RETURNING %s)
+			INSERT INTO %s (%s) SELECT %s FROM moved`,
+			pgx.Identifier{defaultPartition}.Sanitize(), from.Format(time.RFC3339), to.Format(time.RFC3339), columns,
+			table, columns, columns),
+		fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES %s`, parent, table, bounds),
+		fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s`, table, pgx.Identifier{name + "_bounds"}.Sanitize()),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("partition: create %s with rows from %s failed: %w", name, defaultPartition, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("partition: commit %s failed: %w", name, err)
+	}
+	log.Printf("[info] moved rows of %s out of the default partition %s", name, defaultPartition)
+	return nil
+}
+
+// Missing returns the gaps between the earliest partition and the end of
+// the premade periods after now, i.e. times whose rows land in the default
+// partition (or are rejected if there is none).
+func (m *Manager) Missing(ctx context.Context, now time.Time) ([]Range, error) {
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	horizon := m.periodStart(now)
+	for i := 0; i <= m.premake; i++ {
+		horizon = m.nextPeriod(horizon)
+	}
+
+	var gaps []Range
+	var covered time.Time
+	for _, p := range existing {
+		if p.IsDefault {
+			continue
+		}
+		if !covered.IsZero() && p.From.After(covered) {
+			gaps = append(gaps, Range{From: covered, To: p.From})
+		}
+		if p.To.After(covered) {
+			covered = p.To
+		}
+	}
+
+	if covered.IsZero() {
+		return []Range{{From: m.periodStart(now), To: horizon}}, nil
+	}
+	if covered.Before(horizon) {
+		gaps = append(gaps, Range{From: covered, To: horizon})
+	}
+
+	return gaps, nil
+}
+
+// DefaultRows returns the number of rows in the default partition.
+func (m *Manager) DefaultRows(ctx context.Context) (int64, error) {
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range existing {
+		if p.IsDefault {
+			return m.RowCount(ctx, p)
+		}
+	}
+
+	return 0, nil
+}
+
+// overlaps reports whether [from, to) intersects any bounded partition.
+func overlaps(existing []Partition, from, to time.Time) bool {
+	for _, p := range existing {
+		if p.IsDefault {
+			continue
+		}
+		if p.From.Before(to) && from.Before(p.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// Drop removes a bounded partition together with its rows. Before the drop
+// the IDs of the rows are passed to beforeDrop in chunks of at most
+// chunkSize, e.g. to evict them from a cache. The partition is detached
+// before it is dropped, see detach.
+func (m *Manager) Drop(ctx context.Context, p Partition, chunkSize int, beforeDrop func([]int) error) error {
+	if p.IsDefault {
+		return fmt.Errorf("partition: refusing to drop default partition %s", p.Name)
+	}
+
+	name := pgx.Identifier{p.Name}.Sanitize()
+
+	if beforeDrop != nil {
+		rows, err := m.pool.Query(ctx, fmt.Sprintf(`SELECT id FROM %s`, name))
+		if err != nil {
+			return fmt.Errorf("partition: list ids of %s failed: %w", p.Name, err)
+		}
+
+		chunk := make([]int, 0, chunkSize)
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("partition: row scan error: %w", err)
+			}
+			chunk = append(chunk, id)
+			if len(chunk) == chunkSize {
+				if err := beforeDrop(chunk); err != nil {
+					rows.Close()
+					return err
+				}
+				chunk = chunk[:0]
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("partition: rows error: %w", err)
+		}
+		if len(chunk) > 0 {
+			if err := beforeDrop(chunk); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := m.detach(ctx, p); err != nil {
+		return err
+	}
+
+	// Detached, the drop no longer locks the parent table.
+	if _, err := m.pool.Exec(ctx, fmt.Sprintf(`DROP TABLE %s`, name)); err != nil {
+		return fmt.Errorf("partition: drop %s failed: %w", p.Name, err)
+	}
+	return nil
+}
+
+// detach removes p from the parent table. DETACH ... CONCURRENTLY only
+// takes SHARE UPDATE EXCLUSIVE on the parent, so ingest and search go on.
+// Postgres does not allow it while a default partition exists; the plain
+// DETACH is then used with a short lock_timeout so a long-running query
+// cannot make it queue up all other access to the table.
+func (m *Manager) detach(ctx context.Context, p Partition) error {
+	name := pgx.Identifier{p.Name}.Sanitize()
+	parent := pgx.Identifier{m.table}.Sanitize()
+
+	// No row means an earlier run detached it but failed to drop it. A
+	// concurrent detach that was interrupted has to be finalized.
+	var pending bool
+	err := m.pool.QueryRow(ctx, `
+		SELECT inhdetachpending FROM pg_inherits
+		WHERE inhrelid = to_regclass($1) AND inhparent = to_regclass($2)`,
+		p.Name, m.table,
+	).Scan(&pending)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("partition: detach state of %s failed: %w", p.Name, err)
+	}
+	if pending {
+		if _, err := m.pool.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s FINALIZE`, parent, name)); err != nil {
+			return fmt.Errorf("partition: finalize detach of %s failed: %w", p.Name, err)
+		}
+		return nil
+	}
+
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return err
+	}
+	hasDefault := false
+	for _, e := range existing {
+		hasDefault = hasDefault || e.IsDefault
+	}
+
+	if !hasDefault {
+		// Must not run inside a transaction block.
+		if _, err := m.pool.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s CONCURRENTLY`, parent, name)); err != nil {
+			return fmt.Errorf("partition: detach %s failed: %w", p.Name, err)
+		}
+		return nil
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("partition: begin failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SET LOCAL lock_timeout = '5s'`); err != nil {
+		return fmt.Errorf("partition: set lock timeout failed: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, parent, name)); err != nil {
+		return fmt.Errorf("partition: detach %s failed: %w", p.Name, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("partition: commit detach of %s failed: %w", p.Name, err)
+	}
+	return nil
+}
+
+// RowCount returns the number of rows in partition p.
+func (m *Manager) RowCount(ctx context.Context, p Partition) (int64, error) {
+	var n int64
+	query := fmt.Sprintf(`SELECT count(*) FROM %s`, pgx.Identifier{p.Name}.Sanitize())
+	if err := m.pool.QueryRow(ctx, query).Scan(&n); err != nil {
+		return 0, fmt.Errorf("partition: count rows of %s failed: %w", p.Name, err)
+	}
+	return n, nil
+}
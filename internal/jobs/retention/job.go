@@ -0,0 +1,278 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/config"
+	retentioncfg "github.com/julian-richter/ApiTemplate/internal/config/retention"
+	"github.com/julian-richter/ApiTemplate/internal/jobs/partition"
+	model "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+	repo "github.com/julian-richter/ApiTemplate/internal/repos/logentry"
+)
+
+// rule is a normalized retention rule. Empty Service or Level match any.
+type rule struct {
+	Service string
+	Level   string
+	MaxAge  time.Duration
+}
+
+// specificity ranks rules so that an entry is governed by the most specific
+// rule matching it: service+level, then service, then level, then catch-all.
+func (r rule) specificity() int {
+	n := 0
+	if r.Service != "" {
+		n += 2
+	}
+	if r.Level != "" {
+		n++
+	}
+	return n
+}
+
+// overlaps reports whether some entry can match both r and o.
+func (r rule) overlaps(o rule) bool {
+	return (r.Service == "" || o.Service == "" || r.Service == o.Service) &&
+		(r.Level == "" || o.Level == "" || r.Level == o.Level)
+}
+
+func (r rule) String() string {
+	service, level := r.Service, r.Level
+	if service == "" {
+		service = retentioncfg.Wildcard
+	}
+	if level == "" {
+		level = retentioncfg.Wildcard
+	}
+	return fmt.Sprintf("%s:%s=%s", service, level, r.MaxAge)
+}
+
+// Report summarizes one retention pass. In dry-run mode it holds what would
+// have been removed.
+type Report struct {
+	Deleted           map[string]int64 // entries per rule
+	DroppedPartitions []string
+	DroppedRows       int64
+}
+
+// Job periodically removes log entries that are older than their retention
+// rule allows. Entries matched by no rule are kept.
+type Job struct {
+	repo        *repo.Repo
+	partitions  *partition.Manager // may be nil
+	rules       []rule
+	dryRun      bool
+	batchSize   int
+	interval    time.Duration
+	restoreHold time.Duration // restored entries are kept this long
+}
+
+// NewJob creates a retention job from cfg. Partitions, if non-nil, is used
+// to drop whole partitions once every entry in them has expired.
+func NewJob(logRepo *repo.Repo, partitions *partition.Manager, cfg config.Config) (*Job, error) {
+	rules := make([]rule, 0, len(cfg.Retention.Rules))
+	for _, r := range cfg.Retention.Rules {
+		nr := rule{MaxAge: r.MaxAge}
+		if r.Service != retentioncfg.Wildcard {
+			nr.Service = r.Service
+		}
+		if r.Level != retentioncfg.Wildcard {
+			sev, err := model.ParseSeverity(r.Level)
+			if err != nil {
+				return nil, fmt.Errorf("retention: rule %s:%s: %w", r.Service, r.Level, err)
+			}
+			nr.Level = sev.String()
+		}
+		for _, existing := range rules {
+			if existing.Service == nr.Service && existing.Level == nr.Level {
+				return nil, fmt.Errorf("retention: duplicate rule %s", nr)
+			}
+		}
+		rules = append(rules, nr)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].specificity() > rules[j].specificity()
+	})
+
+	return &Job{
+		repo:        logRepo,
+		partitions:  partitions,
+		rules:       rules,
+		dryRun:      cfg.Retention.DryRun,
+		batchSize:   cfg.Retention.BatchSize,
+		interval:    cfg.Retention.Interval,
+		restoreHold: cfg.Archive.RestoreHold,
+	}, nil
+}
+
+// Run purges immediately and then every interval until ctx is cancelled.
+func (j *Job) Run(ctx context.Context) {
+	if len(j.rules) == 0 {
+		log.Printf("[warning] retention job has no rules, nothing to do")
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := j.RunOnce(ctx, time.Now())
+		j.logReport(report)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[warning] retention pass failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single retention pass relative to now.
+func (j *Job) RunOnce(ctx context.Context, now time.Time) (Report, error) {
+	report := Report{Deleted: map[string]int64{}}
+
+	dropped, err := j.dropPartitions(ctx, now, &report)
+	if err != nil {
+		return report, err
+	}
+
+	for i, r := range j.rules {
+		filter := repo.PurgeFilter{
+			Scope:             repo.PurgeScope{Service: r.Service, Level: r.Level},
+			Before:            now.Add(-r.MaxAge),
+			KeepRestoredSince: now.Add(-j.restoreHold),
+			// Entries of dropped partitions are counted in the report
+			// already; in a dry run they are still there.
+			ExceptRanges: dropped,
+		}
+		// More specific rules govern the entries they match.
+		for _, o := range j.rules[:i] {
+			if o.specificity() > r.specificity() && o.overlaps(r) {
+				filter.Except = append(filter.Except, repo.PurgeScope{Service: o.Service, Level: o.Level})
+			}
+		}
+
+		if j.dryRun {
+			n, err := j.repo.CountPurgeable(ctx, filter)
+			if err != nil {
+				return report, err
+			}
+			report.Deleted[r.String()] = n
+			continue
+		}
+
+		for {
+			ids, err := j.repo.Purge(ctx, filter, j.batchSize)
+			report.Deleted[r.String()] += int64(len(ids))
+			if err != nil {
+				return report, err
+			}
+			if len(ids) < j.batchSize {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// dropPartitions drops partitions lying entirely before the longest
+// retention. This only applies with a catch-all rule, since otherwise
+// entries matched by no rule must be kept. Partitions holding restored
+// entries are kept until the restore hold has passed. It returns the time
+// ranges of the dropped partitions.
+func (j *Job) dropPartitions(ctx context.Context, now time.Time, report *Report) ([]repo.TimeRange, error) {
+	if j.partitions == nil {
+		return nil, nil
+	}
+
+	var catchAll bool
+	var longest time.Duration
+	for _, r := range j.rules {
+		if r.Service == "" && r.Level == "" {
+			catchAll = true
+		}
+		if r.MaxAge > longest {
+			longest = r.MaxAge
+		}
+	}
+	if !catchAll {
+		return nil, nil
+	}
+
+	existing, err := j.partitions.Partitions(ctx)
+	if errors.Is(err, partition.ErrNotPartitioned) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []repo.TimeRange
+	cutoff := now.Add(-longest)
+	for _, p := range existing {
+		if p.IsDefault || p.To.After(cutoff) {
+			continue
+		}
+
+		held, err := j.repo.HasRestoredSince(ctx, p.From, p.To, now.Add(-j.restoreHold))
+		if err != nil {
+			return dropped, err
+		}
+		if held {
+			continue
+		}
+
+		n, err := j.partitions.RowCount(ctx, p)
+		if err != nil {
+			return dropped, err
+		}
+
+		if !j.dryRun {
+			evict := func(ids []int) error {
+				j.repo.Evict(ctx, ids...)
+				return nil
+			}
+			if err := j.partitions.Drop(ctx, p, j.batchSize, evict); err != nil {
+				return dropped, err
+			}
+		}
+
+		report.DroppedPartitions = append(report.DroppedPartitions, p.Name)
+		report.DroppedRows += n
+		dropped = append(dropped, repo.TimeRange{From: p.From, To: p.To})
+	}
+
+	return dropped, nil
+}
+
+func (j *Job) logReport(report Report) {
+	verb := "deleted"
+	if j.dryRun {
+		verb = "dry run: would delete"
+	}
+
+	for _, name := range report.DroppedPartitions {
+		log.Printf("[info] retention %s partition %s", verb, name)
+	}
+	if report.DroppedRows > 0 {
+		log.Printf("[info] retention %s %d entries by dropping partitions", verb, report.DroppedRows)
+	}
+	for _, r := range j.rules {
+		if n := report.Deleted[r.String()]; n > 0 {
+			log.Printf("[info] retention %s %d entries for rule %s", verb, n, r)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package logentry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// FingerprintLength is the length of a fingerprint in hex characters.
+const FingerprintLength = 16
+
+// Variable parts of messages, masked in this order so that e.g. the digits
+// of a UUID are not masked as numbers first.
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b(?:0x[0-9a-f]+|[0-9a-f]{8,})\b`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeMessage masks the variable parts of a message: UUIDs become
+// <uuid>, hex identifiers <hex> and numbers <num>. Whitespace runs are
+// collapsed, so messages differing only in such values normalize equally.
+func NormalizeMessage(message string) string {
+	s := uuidPattern.ReplaceAllString(message, "<uuid>")
+	s = hexPattern.ReplaceAllStringFunc(s, func(m string) string {
+		// Hashes and IDs mix digits and letters; plain words and plain
+		// numbers are left to the other rules.
+		if strings.HasPrefix(strings.ToLower(m), "0x") ||
+			(strings.ContainsAny(m, "0123456789") && strings.ContainsAny(strings.ToLower(m), "abcdef")) {
+			return "<hex>"
+		}
+		return m
+	})
+	s = numberPattern.ReplaceAllString(s, "<num>")
+	return strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+}
+
+// Fingerprint identifies repeats of the same message: a hash of the level
+// and the normalized message.
+func Fingerprint(level, message string) string {
+	sum := sha256.Sum256([]byte(level + "\x00" + NormalizeMessage(message)))
+	return hex.EncodeToString(sum[:])[:FingerprintLength]
+}
+
+// SetFingerprint computes Fingerprint from Level and Message.
+func (l *LogEntry) SetFingerprint() {
+	l.Fingerprint = Fingerprint(l.Level, l.Message)
+}
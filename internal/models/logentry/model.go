@@ -0,0 +1,72 @@
+package logentry
+
+import (
+	"time"
+
+	"github.com/julian-richter/ApiTemplate/internal/models"
+)
+
+// LogEntry represents an application log entry.
+type LogEntry struct {
+	ID        int       `json:"id,omitempty" db:"id"`
+	Level     string    `json:"level" db:"level"`
+	Severity  Severity  `json:"severity" db:"severity"`
+	Message   string    `json:"message" db:"message"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	// Attributes holds structured key/value context, stored as JSONB.
+	Attributes map[string]any `json:"attributes,omitempty" db:"attributes"`
+
+	// Provenance: which deployment produced the entry.
+	Service     string `json:"service,omitempty" db:"service"`
+	Host        string `json:"host,omitempty" db:"host"`
+	Environment string `json:"environment,omitempty" db:"environment"`
+	Version     string `json:"version,omitempty" db:"version"`
+
+	// Trace correlation (W3C Trace Context hex IDs), empty if unknown.
+	TraceID string `json:"trace_id,omitempty" db:"trace_id"`
+	SpanID  string `json:"span_id,omitempty" db:"span_id"`
+
+	// Fingerprint identifies repeats of the same message (see Fingerprint).
+	// With duplicate suppression, repeats within the window are collapsed
+	// into one row counting Occurrences between FirstSeen and LastSeen.
+	Fingerprint string    `json:"fingerprint,omitempty" db:"fingerprint"`
+	Occurrences int       `json:"occurrences" db:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen" db:"first_seen"`
+	LastSeen    time.Time `json:"last_seen" db:"last_seen"`
+
+	// RestoredAt is set on entries re-imported from the archive. Such
+	// entries are neither archived again nor purged by retention until the
+	// restore hold has passed.
+	RestoredAt *time.Time `json:"restored_at,omitempty" db:"restored_at"`
+
+	// Headline is a highlighted message snippet, only set by full-text
+	// searches that ask for it. It is not stored.
+	Headline string `json:"headline,omitempty" db:"-"`
+}
+
+func (l *LogEntry) GetID() int64 {
+	return int64(l.ID)
+}
+
+func (l *LogEntry) SetID(id int64) {
+	l.ID = int(id)
+}
+
+// Normalize canonicalizes Level and sets the matching Severity.
+// It returns ErrUnknownLevel if Level cannot be mapped.
+func (l *LogEntry) Normalize() error {
+	s, err := ParseSeverity(l.Level)
+	if err != nil {
+		return err
+	}
+	l.SetSeverity(s)
+	return nil
+}
+
+// SetSeverity sets Severity and the matching canonical Level.
+func (l *LogEntry) SetSeverity(s Severity) {
+	l.Severity = s
+	l.Level = s.String()
+}
+
+var _ models.Entity = (*LogEntry)(nil)
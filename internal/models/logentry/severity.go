@@ -0,0 +1,133 @@
+package logentry
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownLevel is returned when a level name cannot be mapped to a Severity.
+var ErrUnknownLevel = errors.New("unknown log level")
+
+// Severity is the canonical, ordered log severity. The numeric values are
+// the first number of each OpenTelemetry SeverityNumber range, so they can
+// be compared directly and exported to OTel unchanged.
+type Severity int
+
+const (
+	SeverityTrace Severity = 1
+	SeverityDebug Severity = 5
+	SeverityInfo  Severity = 9
+	SeverityWarn  Severity = 13
+	SeverityError Severity = 17
+	SeverityFatal Severity = 21
+)
+
+// severityNames maps the canonical severities to the level stored in LogEntry.Level.
+var severityNames = map[Severity]string{
+	SeverityTrace: "trace",
+	SeverityDebug: "debug",
+	SeverityInfo:  "info",
+	SeverityWarn:  "warn",
+	SeverityError: "error",
+	SeverityFatal: "fatal",
+}
+
+// severityAliases maps accepted level spellings (lower case) to a Severity.
+var severityAliases = map[string]Severity{
+	"trace":         SeverityTrace,
+	"debug":         SeverityDebug,
+	"dbg":           SeverityDebug,
+	"info":          SeverityInfo,
+	"information":   SeverityInfo,
+	"informational": SeverityInfo,
+	"notice":        SeverityInfo,
+	"warn":          SeverityWarn,
+	"warning":       SeverityWarn,
+	"error":         SeverityError,
+	"err":           SeverityError,
+	"fatal":         SeverityFatal,
+	"critical":      SeverityFatal,
+	"crit":          SeverityFatal,
+	"alert":         SeverityFatal,
+	"emerg":         SeverityFatal,
+	"emergency":     SeverityFatal,
+	"panic":         SeverityFatal,
+}
+
+// ParseSeverity normalizes a level name such as "ERROR", "err" or "Warning"
+// to its canonical Severity. OTel severity numbers ("1".."24") are accepted too.
+func ParseSeverity(level string) (Severity, error) {
+	name := strings.ToLower(strings.TrimSpace(level))
+	if s, ok := severityAliases[name]; ok {
+		return s, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= 24 {
+		return SeverityFromOTel(n), nil
+	}
+	return 0, ErrUnknownLevel
+}
+
+// SeverityFromOTel maps an OpenTelemetry SeverityNumber (1-24) to the
+// canonical severity of its range. Unspecified (0) maps to info.
+func SeverityFromOTel(n int) Severity {
+	switch {
+	case n <= 0:
+		return SeverityInfo
+	case n >= int(SeverityFatal):
+		return SeverityFatal
+	default:
+		return Severity((n-1)/4*4 + 1)
+	}
+}
+
+// SeverityFromSyslog maps a syslog severity (RFC 5424, 0 emergency to 7 debug).
+func SeverityFromSyslog(n int) Severity {
+	switch {
+	case n <= 2:
+		return SeverityFatal
+	case n == 3:
+		return SeverityError
+	case n == 4:
+		return SeverityWarn
+	case n <= 6:
+		return SeverityInfo
+	default:
+		return SeverityDebug
+	}
+}
+
+// Valid reports whether s is one of the canonical severities.
+func (s Severity) Valid() bool {
+	_, ok := severityNames[s]
+	return ok
+}
+
+// String returns the canonical level name, e.g. "warn".
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// OTel returns the OpenTelemetry SeverityNumber.
+func (s Severity) OTel() int {
+	return int(s)
+}
+
+// Syslog returns the closest syslog severity (RFC 5424).
+func (s Severity) Syslog() int {
+	switch {
+	case s >= SeverityFatal:
+		return 2
+	case s >= SeverityError:
+		return 3
+	case s >= SeverityWarn:
+		return 4
+	case s >= SeverityInfo:
+		return 6
+	default:
+		return 7
+	}
+}
@@ -0,0 +1,44 @@
+package logentry
+
+import "strings"
+
+// Lengths of W3C Trace Context identifiers in hex characters.
+const (
+	TraceIDLength = 32
+	SpanIDLength  = 16
+)
+
+// ValidTraceID reports whether id is a 32 character lower-case hex trace ID
+// that is not all zeros, as required by W3C Trace Context.
+func ValidTraceID(id string) bool {
+	return validHexID(id, TraceIDLength)
+}
+
+// ValidSpanID reports whether id is a 16 character lower-case hex span ID
+// that is not all zeros.
+func ValidSpanID(id string) bool {
+	return validHexID(id, SpanIDLength)
+}
+
+func validHexID(id string, length int) bool {
+	if len(id) != length {
+		return false
+	}
+	nonZero := false
+	for _, c := range id {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			nonZero = true
+		default:
+			return false
+		}
+	}
+	return nonZero
+}
+
+// NormalizeTraceID lower-cases and trims a trace or span ID so upper-case
+// input from clients matches stored IDs.
+func NormalizeTraceID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
@@ -0,0 +1,115 @@
+package logentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAttributeFilter is returned for malformed attribute filters.
+var ErrInvalidAttributeFilter = errors.New("invalid attribute filter")
+
+// AttributeOp is the comparison applied by an AttributeFilter.
+type AttributeOp string
+
+const (
+	AttrEq  AttributeOp = "="
+	AttrNe  AttributeOp = "!="
+	AttrGt  AttributeOp = ">"
+	AttrGte AttributeOp = ">="
+	AttrLt  AttributeOp = "<"
+	AttrLte AttributeOp = "<="
+)
+
+// AttributeFilter matches a (possibly nested) key of LogEntry.Attributes.
+// Equality uses JSONB containment and can use the GIN index; ordering
+// comparisons only match numeric attribute values.
+type AttributeFilter struct {
+	Path  []string
+	Op    AttributeOp
+	Value string
+}
+
+// attributeFilterPattern splits "http.status>=500" into path, operator and value.
+// Longer operators come first so ">=" is not read as ">" followed by "=500".
+var attributeFilterPattern = regexp.MustCompile(`^([A-Za-z0-9_\-]+(?:\.[A-Za-z0-9_\-]+)*)(!=|>=|<=|=|>|<)(.*)$`)
+
+// ParseAttributeFilter parses an expression such as "user_id=42" or
+// "http.status>=500". Dots separate nested keys.
+func ParseAttributeFilter(expr string) (AttributeFilter, error) {
+	m := attributeFilterPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return AttributeFilter{}, fmt.Errorf("%w: %q", ErrInvalidAttributeFilter, expr)
+	}
+
+	f := AttributeFilter{
+		Path:  strings.Split(m[1], "."),
+		Op:    AttributeOp(m[2]),
+		Value: m[3],
+	}
+
+	if f.Op != AttrEq && f.Op != AttrNe {
+		if _, err := strconv.ParseFloat(f.Value, 64); err != nil {
+			return AttributeFilter{}, fmt.Errorf("%w: %q needs a numeric value", ErrInvalidAttributeFilter, expr)
+		}
+	}
+
+	return f, nil
+}
+
+// jsonValue interprets the filter value as JSON where possible, so 42 and
+// true match numbers and booleans. Anything else, or a value in double
+// quotes, is matched as a string.
+func (f AttributeFilter) jsonValue() any {
+	var v any
+	if err := json.Unmarshal([]byte(f.Value), &v); err == nil {
+		return v
+	}
+	return f.Value
+}
+
+// containment returns the JSON document {"a":{"b":value}} for the path a.b.
+func (f AttributeFilter) containment() (string, error) {
+	doc := f.jsonValue()
+	for i := len(f.Path) - 1; i >= 0; i-- {
+		doc = map[string]any{f.Path[i]: doc}
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// clause renders the SQL condition for the filter starting at placeholder
+// argPos and returns it with its arguments.
+func (f AttributeFilter) clause(argPos int) (string, []any, error) {
+	switch f.Op {
+	case AttrEq, AttrNe:
+		doc, err := f.containment()
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidAttributeFilter, err)
+		}
+		cond := fmt.Sprintf("attributes @> $%d::jsonb", argPos)
+		if f.Op == AttrNe {
+			cond = "NOT (" + cond + ")"
+		}
+		return cond, []any{doc}, nil
+	case AttrGt, AttrGte, AttrLt, AttrLte:
+		n, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %q is not numeric", ErrInvalidAttributeFilter, f.Value)
+		}
+		// The CASE keeps non-numeric values from failing the cast.
+		cond := fmt.Sprintf(
+			"(CASE WHEN jsonb_typeof(attributes #> $%[1]d::text[]) = 'number' THEN (attributes #>> $%[1]d::text[])::numeric END) %[2]s $%[3]d",
+			argPos, f.Op, argPos+1,
+		)
+		return cond, []any{f.Path, n}, nil
+	default:
+		return "", nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidAttributeFilter, f.Op)
+	}
+}
@@ -0,0 +1,68 @@
+package logentry
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	modelpkg "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies the position of an entry in the (timestamp, id) ordering
+// used by keyset pagination. Clients only ever see its opaque encoding.
+type Cursor struct {
+	Timestamp time.Time
+	ID        int
+}
+
+// CursorFor returns the cursor pointing at the given entry.
+func CursorFor(entry *modelpkg.LogEntry) Cursor {
+	return Cursor{Timestamp: entry.Timestamp, ID: entry.ID}
+}
+
+// Encode returns the opaque, URL-safe token for the cursor. The timestamp
+// is written as seconds and nanoseconds, UnixNano would overflow outside
+// the years 1678 to 2262.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d.%09d:%d", c.Timestamp.Unix(), c.Timestamp.Nanosecond(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	tsPart, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	secPart, nsecPart, ok := strings.Cut(tsPart, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	sec, err := strconv.ParseInt(secPart, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	nsec, err := strconv.ParseInt(nsecPart, 10, 64)
+	if err != nil || nsec < 0 || nsec >= int64(time.Second) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{Timestamp: time.Unix(sec, nsec).UTC(), ID: id}, nil
+}
@@ -0,0 +1,272 @@
+package logentry
+
+import (
+	"errors"
+	"text/template"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	dbpkg "github.com/julian-richter/ApiTemplate/internal/db"
+	modelpkg "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// textSearchConfig is the text search configuration of the generated
+// search_vector column. "simple" does no stemming, which suits identifiers
+// and error codes in log messages.
+const textSearchConfig = "simple"
+
+// exportFetchSize is the number of rows pulled per FETCH by Repo.Export.
+const exportFetchSize = 1000
+
+// Sentinel not-found error used by handlers.
+var ErrNotFound = errors.New("log entry not found")
+
+// ErrInvalidRegex is returned when SearchParams.MessageRegex does not compile
+// or uses syntax that Postgres rejects.
+var ErrInvalidRegex = errors.New("invalid message regex")
+
+// ErrUnknownFacet is returned when facet counts are requested for a field
+// that is not in facetColumns.
+var ErrUnknownFacet = errors.New("unknown facet field")
+
+// facetColumns whitelists the fields that can be faceted on and maps them
+// to their column.
+var facetColumns = map[string]string{
+	"level":       "level",
+	"service":     "service",
+	"host":        "host",
+	"environment": "environment",
+}
+
+// ValidFacet reports whether facet counts can be requested for field.
+func ValidFacet(field string) bool {
+	_, ok := facetColumns[field]
+	return ok
+}
+
+// RepoOption applies optional settings to Repo.
+type RepoOption func(*Repo)
+
+// BatchOption applies per-call settings to SaveBatch.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	skipDedup bool
+}
+
+// Repo persists and optionally caches log entries.
+type Repo struct {
+	pgPool      *pgxpool.Pool
+	cacheClient dbpkg.ValkeyClientInterface
+	cachePrefix string
+	dedupWindow time.Duration // 0 disables duplicate suppression
+}
+
+// MatchMode controls how multiple message terms are combined.
+type MatchMode int
+
+const (
+	MatchAll MatchMode = iota // every term must match (AND)
+	MatchAny                  // at least one term must match (OR)
+)
+
+// SearchParams holds optional filters for searching log entries.
+type SearchParams struct {
+	Levels          []string          // entry level must be one of these, empty means ignore
+	ExcludeLevels   []string          // entry level must be none of these
+	MinSeverity     modelpkg.Severity // if non-zero, only entries at or above this severity
+	MessageContains []string          // substrings to search in message, combined by MessageMatch
+	ExcludeMessages []string          // substrings the message must not contain
+	MessageMatch    MatchMode         // how MessageContains terms combine, default MatchAll
+	MessageRegex    string            // case-insensitive regular expression on message, empty means ignore
+	Query           string            // full-text query in websearch syntax, empty means ignore
+	Highlight       bool              // fill LogEntry.Headline with ts_headline snippets (needs Query)
+	Attributes      []AttributeFilter // conditions on structured attributes, all must match
+	Services        []string          // producing service must be one of these, empty means ignore
+	Hosts           []string          // producing host must be one of these, empty means ignore
+	Environments    []string          // environment must be one of these, empty means ignore
+	Versions        []string          // service version must be one of these, empty means ignore
+	TraceID         string            // exact trace ID, empty means ignore
+	SpanID          string            // exact span ID, empty means ignore
+	Fingerprints    []string          // message fingerprint must be one of these, empty means ignore
+	ExcludeRestored bool              // skip entries re-imported from the archive
+	Since           *time.Time        // if non-nil, only entries after this time
+	Until           *time.Time        // if non-nil, only entries before this time
+	Sort            []SortField       // result ordering, empty means timestamp DESC, id DESC
+	After           *Cursor           // if non-nil, keyset mode: only entries after this cursor, Offset is ignored
+	Limit           int               // max results to return (0 means default)
+	Offset          int               // number of results to skip
+}
+
+// PurgeScope selects entries by service and level. An empty field matches
+// any value.
+type PurgeScope struct {
+	Service string
+	Level   string
+}
+
+// TimeRange is a half-open time range [From, To).
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// PurgeFilter selects entries older than Before within Scope, except those
+// that also fall into one of the Except scopes or ExceptRanges. Entries
+// restored from the archive at or after KeepRestoredSince are kept; zero
+// keeps none of them.
+type PurgeFilter struct {
+	Scope             PurgeScope
+	Except            []PurgeScope
+	ExceptRanges      []TimeRange
+	Before            time.Time
+	KeepRestoredSince time.Time
+}
+
+// insertColumns lists the columns written for a new entry, in the order of
+// insertValues and the "insert" template placeholders.
+var insertColumns = []string{
+	"level", "severity", "message", "timestamp", "attributes",
+	"service", "host", "environment", "version",
+	"trace_id", "span_id",
+	"fingerprint", "occurrences", "first_seen", "last_seen", "restored_at",
+}
+
+// Template definitions for SQL queries.
+var (
+	// Use `define` so you can reuse parts if needed later.
+	queryTmpl = template.Must(template.New("logentry_queries").Parse(`
+		{{ define "columns" }}id, level, severity, message, timestamp, attributes, service, host, environment, version, trace_id, span_id, fingerprint, occurrences, first_seen, last_seen, restored_at{{ end }}
+
+		{{ define "insert" }}
+			INSERT INTO {{ .Table }} (level, severity, message, timestamp, attributes, service, host, environment, version, trace_id, span_id, fingerprint, occurrences, first_seen, last_seen, restored_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			RETURNING id
+		{{ end }}
+
+		{{ define "dedupInsert" }}
+			WITH target AS (
+				SELECT id AS target_id, timestamp AS target_timestamp
+				FROM {{ .Table }}
+				WHERE fingerprint = $12::text
+				  AND service = $6::text
+				  AND timestamp > $4::timestamptz - make_interval(secs => $17::float8)
+				  AND timestamp <= $4::timestamptz
+				  AND restored_at IS NULL
+				ORDER BY timestamp DESC
+				LIMIT 1
+			), collapsed AS (
+				UPDATE {{ .Table }}
+				SET occurrences = occurrences + $13::integer,
+				    last_seen = greatest(last_seen, $15::timestamptz)
+				FROM target
+				WHERE id = target_id AND timestamp = target_timestamp
+				RETURNING {{ template "columns" }}
+			), inserted AS (
+				INSERT INTO {{ .Table }} (level, severity, message, timestamp, attributes, service, host, environment, version, trace_id, span_id, fingerprint, occurrences, first_seen, last_seen, restored_at)
+				SELECT $1::text, $2::smallint, $3::text, $4::timestamptz, $5::jsonb, $6::text, $7::text, $8::text, $9::text, $10::text, $11::text, $12::text, $13::integer, $14::timestamptz, $15::timestamptz, $16::timestamptz
+				WHERE NOT EXISTS (SELECT 1 FROM target)
+				RETURNING {{ template "columns" }}
+			)
+			SELECT {{ template "columns" }}, false FROM collapsed
+			UNION ALL
+			SELECT {{ template "columns" }}, true FROM inserted
+		{{ end }}
+
+		{{ define "update" }}
+			UPDATE {{ .Table }}
+			SET level = $1,
+			    severity = $2,
+			    message = $3,
+			    timestamp = $4,
+			    attributes = $5,
+			    service = $6,
+			    host = $7,
+			    environment = $8,
+			    version = $9,
+			    trace_id = $10,
+			    span_id = $11,
+			    fingerprint = $12
+			WHERE id = $13
+			RETURNING occurrences, first_seen, last_seen
+		{{ end }}
+
+		{{ define "delete" }}
+			DELETE FROM {{ .Table }}
+			WHERE id = $1
+		{{ end }}
+
+        {{ define "selectByID" }}
+			SELECT {{ template "columns" }}
+			FROM {{ .Table }}
+			WHERE id = $1
+        {{ end }}
+
+        {{ define "selectAll" }}
+			SELECT {{ template "columns" }}
+			FROM {{ .Table }}
+        {{ end }}
+
+        {{ define "search" }}
+			SELECT {{ template "columns" }}{{ if .Headline }}, {{ .Headline }}{{ end }}
+			FROM {{ .Table }}
+			WHERE {{ .WhereClause }}
+			ORDER BY {{ .OrderBy }}
+			LIMIT ${{ .LimitPos }} OFFSET ${{ .OffsetPos }}
+        {{ end }}
+
+        {{ define "count" }}
+			SELECT count(*)
+			FROM {{ .Table }}
+			WHERE {{ .WhereClause }}
+        {{ end }}
+
+        {{ define "estimate" }}
+			EXPLAIN (FORMAT JSON)
+			SELECT 1
+			FROM {{ .Table }}
+			WHERE {{ .WhereClause }}
+        {{ end }}
+
+        {{ define "facet" }}
+			SELECT {{ .Column }}, count(*)
+			FROM {{ .Table }}
+			WHERE {{ .WhereClause }}
+			GROUP BY {{ .Column }}
+        {{ end }}
+
+        {{ define "exportDeclare" }}
+			DECLARE {{ .Cursor }} NO SCROLL CURSOR FOR
+			SELECT {{ template "columns" }}
+			FROM {{ .Table }}
+			WHERE {{ .WhereClause }}
+			ORDER BY {{ .OrderBy }}
+        {{ end }}
+
+        {{ define "purge" }}
+			DELETE FROM {{ .Table }}
+			WHERE (id, timestamp) IN (
+				SELECT id, timestamp
+				FROM {{ .Table }}
+				WHERE {{ .WhereClause }}
+				LIMIT ${{ .LimitPos }}
+			)
+			RETURNING id
+        {{ end }}
+
+        {{ define "deleteIDs" }}
+			DELETE FROM {{ .Table }}
+			WHERE id = ANY($1)
+        {{ end }}
+
+        {{ define "oldest" }}
+			SELECT min(timestamp)
+			FROM {{ .Table }}
+			WHERE timestamp < $1 AND restored_at IS NULL
+        {{ end }}
+
+        {{ define "exportFetch" }}
+			FETCH FORWARD {{ .FetchSize }} FROM {{ .Cursor }}
+        {{ end }}
+    `))
+)
@@ -0,0 +1,965 @@
+package logentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	dbpkg "github.com/julian-richter/ApiTemplate/internal/db"
+	modelpkg "github.com/julian-richter/ApiTemplate/internal/models/logentry"
+)
+
+// WithCache enables caching by providing a cache client and key prefix.
+func WithCache(client dbpkg.ValkeyClientInterface, prefix string) RepoOption {
+	return func(r *Repo) {
+		r.cacheClient = client
+		r.cachePrefix = prefix
+	}
+}
+
+// NewRepo creates a new log entry repository. Pass WithCache if caching is desired.
+func NewRepo(pgPool *pgxpool.Pool, opts ...RepoOption) *Repo {
+	r := &Repo{pgPool: pgPool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Repo) tableName() string {
+	return "log_entries"
+}
+
+func (r *Repo) cacheKey(id int) string {
+	return fmt.Sprintf("%slogentry:%d", r.cachePrefix, id)
+}
+
+// WithDedup enables duplicate suppression: a new entry whose fingerprint
+// and service match an entry stored less than window earlier is counted as
+// another occurrence of that entry instead of being inserted.
+func WithDedup(window time.Duration) RepoOption {
+	return func(r *Repo) {
+		r.dedupWindow = window
+	}
+}
+
+// SkipDedup makes SaveBatch insert every entry as is, bypassing duplicate
+// suppression. It is meant for entries that were already deduplicated when
+// first stored, such as restored archives, and for imports run with
+// -no-dedup.
+func SkipDedup() BatchOption {
+	return func(o *batchOptions) {
+		o.skipDedup = true
+	}
+}
+
+// insertValues returns the values of entry in insertColumns order. It
+// refreshes the fingerprint and fills in the occurrence defaults of a new
+// entry (one occurrence seen at its timestamp).
+func insertValues(entry *modelpkg.LogEntry) []any {
+	attrs := entry.Attributes
+	if attrs == nil {
+		// Store an empty object rather than a JSON null.
+		attrs = map[string]any{}
+	}
+	entry.SetFingerprint()
+	if entry.Occurrences <= 0 {
+		entry.Occurrences = 1
+	}
+	if entry.FirstSeen.IsZero() {
+		entry.FirstSeen = entry.Timestamp
+	}
+	if entry.LastSeen.IsZero() {
+		entry.LastSeen = entry.Timestamp
+	}
+	return []any{
+		entry.Level, int16(entry.Severity), entry.Message, entry.Timestamp, attrs,
+		entry.Service, entry.Host, entry.Environment, entry.Version,
+		entry.TraceID, entry.SpanID,
+		entry.Fingerprint, int32(entry.Occurrences), entry.FirstSeen, entry.LastSeen,
+		entry.RestoredAt,
+	}
+}
+
+// updateValues returns the values bound by the "update" template.
+func updateValues(entry *modelpkg.LogEntry) []any {
+	values := insertValues(entry)
+	// Occurrence columns are maintained by the database, not by updates.
+	return append(values[:12], entry.ID)
+}
+
+// scanTargets returns the scan destinations matching the "columns" template.
+func scanTargets(entry *modelpkg.LogEntry) []any {
+	return []any{
+		&entry.ID, &entry.Level, &entry.Severity, &entry.Message, &entry.Timestamp, &entry.Attributes,
+		&entry.Service, &entry.Host, &entry.Environment, &entry.Version,
+		&entry.TraceID, &entry.SpanID,
+		&entry.Fingerprint, &entry.Occurrences, &entry.FirstSeen, &entry.LastSeen,
+		&entry.RestoredAt,
+	}
+}
+
+// dedupLockBuckets is the number of advisory locks fingerprints are hashed
+// onto. Bounding it keeps large batches from exhausting the lock table.
+const dedupLockBuckets = 1024
+
+// dedupLockKey maps a fingerprint to the advisory lock serializing its
+// duplicate check. Fingerprints sharing a bucket merely serialize.
+func dedupLockKey(fingerprint string) int64 {
+	key, _ := strconv.ParseUint(fingerprint, 16, 64)
+	return int64(key % dedupLockBuckets)
+}
+
+// Save persists or updates a LogEntry, and caches it if configured.
+func (r *Repo) Save(ctx context.Context, entry *modelpkg.LogEntry) error {
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+
+	var query string
+	var err error
+
+	if entry.ID <= 0 && r.dedupWindow > 0 {
+		if err := r.saveDedup(ctx, entry); err != nil {
+			return err
+		}
+	} else if entry.ID <= 0 {
+		// New entry - use INSERT with RETURNING id
+		var buf bytes.Buffer
+		if err = queryTmpl.ExecuteTemplate(&buf, "insert", tmplData); err != nil {
+			return fmt.Errorf("Repo.Save: template execution error for insert: %w", err)
+		}
+		query = buf.String()
+
+		// INSERT (level, ..., restored_at) VALUES ($1..$16) RETURNING id
+		err = r.pgPool.QueryRow(ctx, query, insertValues(entry)...).Scan(&entry.ID)
+
+		if err != nil {
+			return fmt.Errorf("Repo.Save: insert failed: %w", err)
+		}
+	} else {
+		// Existing entry - use UPDATE
+		var buf bytes.Buffer
+		if err = queryTmpl.ExecuteTemplate(&buf, "update", tmplData); err != nil {
+			return fmt.Errorf("Repo.Save: template execution error for update: %w", err)
+		}
+		query = buf.String()
+
+		// UPDATE table SET level=$1, ..., fingerprint=$12 WHERE id=$13
+		err = r.pgPool.QueryRow(ctx, query, updateValues(entry)...).
+			Scan(&entry.Occurrences, &entry.FirstSeen, &entry.LastSeen)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("Repo.Save: update failed: %w", err)
+		}
+	}
+
+	// Update cache if configured
+	if r.cacheClient != nil {
+		key := r.cacheKey(entry.ID)
+		if b, err := json.Marshal(entry); err == nil {
+			if err := r.cacheClient.Set(ctx, key, string(b), 0); err != nil {
+				// Log cache set error but continue (don't return the error)
+				log.Printf("[warning] cache set failed for key %s (TTL: 0): %v", key, err)
+			}
+		} else {
+			// Log json marshal error but continue (don't return the error)
+			log.Printf("[warning] cache set failed for key %s (TTL: 0): json marshal error: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+// SaveBatch inserts many new log entries in one round trip and returns the
+// number of rows written. Without returnIDs the rows are streamed with the
+// COPY protocol and the entries keep ID 0; with returnIDs every entry is
+// inserted through a pipelined INSERT ... RETURNING id inside a single
+// transaction and gets its ID assigned. With duplicate suppression every
+// entry takes the dedup path and always gets an ID, possibly that of the
+// entry it was collapsed into; the count then excludes collapsed entries.
+// Pass SkipDedup to bypass duplicate suppression. Batch inserts are not
+// cached.
+func (r *Repo) SaveBatch(ctx context.Context, entries []*modelpkg.LogEntry, returnIDs bool, opts ...BatchOption) (int64, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	var o batchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if r.dedupWindow > 0 && !o.skipDedup {
+		return r.saveBatchDedup(ctx, entries)
+	}
+
+	if !returnIDs {
+		rows := make([][]any, len(entries))
+		for i, e := range entries {
+			rows[i] = insertValues(e)
+		}
+
+		n, err := r.pgPool.CopyFrom(ctx,
+			pgx.Identifier{r.tableName()},
+			insertColumns,
+			pgx.CopyFromRows(rows),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("Repo.SaveBatch: copy failed: %w", err)
+		}
+		return n, nil
+	}
+
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "insert", tmplData); err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: template execution error for insert: %w", err)
+	}
+	query := buf.String()
+
+	tx, err := r.pgPool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: begin failed: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(query, insertValues(e)...)
+	}
+
+	// Collect IDs first so a failed batch leaves the entries untouched.
+	ids := make([]int, len(entries))
+	results := tx.SendBatch(ctx, batch)
+	for i := range entries {
+		if err := results.QueryRow().Scan(&ids[i]); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("Repo.SaveBatch: insert %d failed: %w", i, err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: batch close failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: commit failed: %w", err)
+	}
+
+	for i, e := range entries {
+		e.ID = ids[i]
+	}
+
+	return int64(len(entries)), nil
+}
+
+// saveDedup inserts entry or collapses it into a recent duplicate, and
+// loads the resulting row into entry.
+func (r *Repo) saveDedup(ctx context.Context, entry *modelpkg.LogEntry) error {
+	query, err := r.dedupQuery()
+	if err != nil {
+		return fmt.Errorf("Repo.Save: %w", err)
+	}
+
+	values := append(insertValues(entry), r.dedupWindow.Seconds())
+
+	tx, err := r.pgPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("Repo.Save: begin failed: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, dedupLockKey(entry.Fingerprint)); err != nil {
+		return fmt.Errorf("Repo.Save: dedup lock failed: %w", err)
+	}
+
+	var stored modelpkg.LogEntry
+	var inserted bool
+	if err := tx.QueryRow(ctx, query, values...).Scan(append(scanTargets(&stored), &inserted)...); err != nil {
+		return fmt.Errorf("Repo.Save: dedup insert failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("Repo.Save: commit failed: %w", err)
+	}
+
+	*entry = stored
+	return nil
+}
+
+// saveBatchDedup is SaveBatch with duplicate suppression. Duplicates within
+// the batch collapse as well, since later statements see earlier rows. It
+// returns the number of rows inserted, not counting collapsed entries.
+func (r *Repo) saveBatchDedup(ctx context.Context, entries []*modelpkg.LogEntry) (int64, error) {
+	query, err := r.dedupQuery()
+	if err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	values := make([][]any, len(entries))
+	var keys []int64
+	for i, e := range entries {
+		values[i] = append(insertValues(e), r.dedupWindow.Seconds())
+		keys = append(keys, dedupLockKey(e.Fingerprint))
+	}
+
+	// Taking the locks in a fixed order keeps concurrent batches from
+	// deadlocking each other.
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	for _, key := range keys {
+		batch.Queue(`SELECT pg_advisory_xact_lock($1)`, key)
+	}
+	for _, v := range values {
+		batch.Queue(query, v...)
+	}
+
+	tx, err := r.pgPool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: begin failed: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	results := tx.SendBatch(ctx, batch)
+	for range keys {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("Repo.SaveBatch: dedup lock failed: %w", err)
+		}
+	}
+
+	// Collect IDs first so a failed batch leaves the entries untouched.
+	stored := make([]modelpkg.LogEntry, len(entries))
+	var n int64
+	for i := range entries {
+		var inserted bool
+		if err := results.QueryRow().Scan(append(scanTargets(&stored[i]), &inserted)...); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("Repo.SaveBatch: dedup insert %d failed: %w", i, err)
+		}
+		if inserted {
+			n++
+		}
+	}
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: batch close failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("Repo.SaveBatch: commit failed: %w", err)
+	}
+
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		e.ID = stored[i].ID
+		e.Occurrences = stored[i].Occurrences
+		e.FirstSeen = stored[i].FirstSeen
+		e.LastSeen = stored[i].LastSeen
+		ids[i] = e.ID
+	}
+
+	// Collapsed rows may be cached with an outdated occurrence count.
+	r.Evict(ctx, ids...)
+
+	return n, nil
+}
+
+// dedupQuery renders the "dedupInsert" template.
+func (r *Repo) dedupQuery() (string, error) {
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "dedupInsert", tmplData); err != nil {
+		return "", fmt.Errorf("template execution error for dedup insert: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GetByID retrieves a LogEntry by ID, optionally using cache.
+func (r *Repo) GetByID(ctx context.Context, id int, useCache bool, ttl time.Duration) (*modelpkg.LogEntry, error) {
+	var entry modelpkg.LogEntry
+
+	if useCache && r.cacheClient != nil {
+		key := r.cacheKey(id)
+		if val, err := r.cacheClient.Get(ctx, key); err == nil {
+			if err2 := json.Unmarshal([]byte(val), &entry); err2 == nil {
+				return &entry, nil
+			}
+		} else {
+			// Log cache get error but continue with DB fallback
+			log.Printf("[warning] cache get failed for key %s: %v", key, err)
+		}
+	}
+
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "selectByID", tmplData); err != nil {
+		return nil, fmt.Errorf("Repo.GetByID: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	row := r.pgPool.QueryRow(ctx, query, id)
+	err := row.Scan(scanTargets(&entry)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("Repo.GetByID: row scan error: %w", err)
+	}
+
+	if useCache && r.cacheClient != nil {
+		key := r.cacheKey(entry.ID)
+		if b, err := json.Marshal(&entry); err == nil {
+			if err := r.cacheClient.Set(ctx, key, string(b), ttl); err != nil {
+				// Log cache set error but continue (don't return the error)
+				log.Printf("[warning] cache set failed for key %s (TTL: %v): %v", key, ttl, err)
+			}
+		} else {
+			// Log json marshal error but continue (don't return the error)
+			log.Printf("[warning] cache set failed for key %s (TTL: %v): json marshal error: %v", key, ttl, err)
+		}
+	}
+
+	return &entry, nil
+}
+
+// Delete removes a LogEntry by ID and evicts its cached copy if configured.
+func (r *Repo) Delete(ctx context.Context, id int) error {
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "delete", tmplData); err != nil {
+		return fmt.Errorf("Repo.Delete: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	tag, err := r.pgPool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("Repo.Delete: delete failed: %w", err)
+	}
+
+	// Evict even if no row was affected, a stale key may still be around.
+	if r.cacheClient != nil {
+		key := r.cacheKey(id)
+		if err := r.cacheClient.Del(ctx, key); err != nil {
+			// Log cache del error but continue (don't return the error)
+			log.Printf("[warning] cache del failed for key %s: %v", key, err)
+		}
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// All returns all log entries, no caching by default.
+func (r *Repo) All(ctx context.Context) ([]*modelpkg.LogEntry, error) {
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "selectAll", tmplData); err != nil {
+		return nil, fmt.Errorf("Repo.All: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	rows, err := r.pgPool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.All: query error: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*modelpkg.LogEntry
+	for rows.Next() {
+		var e modelpkg.LogEntry
+		if err := rows.Scan(scanTargets(&e)...); err != nil {
+			return nil, fmt.Errorf("Repo.All: row scan error: %w", err)
+		}
+		result = append(result, &e)
+	}
+
+	// detect mid-stream errors.
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Repo.All: rows error: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildWhere compiles the filters in SearchParams into a parameterized
+// WHERE clause. Placeholders are numbered from 1, so further arguments
+// continue at len(args)+1.
+func buildWhere(params SearchParams) (string, []any, error) {
+	whereClauses := []string{"1=1"}
+	args := []interface{}{}
+	argPos := 1
+
+	if len(params.Levels) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("level = ANY($%d)", argPos))
+		args = append(args, params.Levels)
+		argPos++
+	}
+	if len(params.ExcludeLevels) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("level <> ALL($%d)", argPos))
+		args = append(args, params.ExcludeLevels)
+		argPos++
+	}
+	if params.MinSeverity > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("severity >= $%d", argPos))
+		args = append(args, int16(params.MinSeverity))
+		argPos++
+	}
+	if len(params.MessageContains) > 0 {
+		terms := make([]string, len(params.MessageContains))
+		for i, term := range params.MessageContains {
+			terms[i] = fmt.Sprintf("message ILIKE $%d", argPos)
+			args = append(args, "%"+term+"%")
+			argPos++
+		}
+		joiner := " AND "
+		if params.MessageMatch == MatchAny {
+			joiner = " OR "
+		}
+		whereClauses = append(whereClauses, "("+strings.Join(terms, joiner)+")")
+	}
+	if params.MessageRegex != "" {
+		if err := ValidateRegex(params.MessageRegex); err != nil {
+			return "", nil, err
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("message ~* $%d", argPos))
+		args = append(args, params.MessageRegex)
+		argPos++
+	}
+	if params.Query != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("search_vector @@ %s", tsQueryExpr(argPos)))
+		args = append(args, params.Query)
+		argPos++
+	}
+	for _, term := range params.ExcludeMessages {
+		whereClauses = append(whereClauses, fmt.Sprintf("message NOT ILIKE $%d", argPos))
+		args = append(args, "%"+term+"%")
+		argPos++
+	}
+	for _, filter := range []struct {
+		column string
+		values []string
+	}{
+		{"service", params.Services},
+		{"host", params.Hosts},
+		{"environment", params.Environments},
+		{"version", params.Versions},
+		{"fingerprint", params.Fingerprints},
+	} {
+		if len(filter.values) > 0 {
+			whereClauses = append(whereClauses, fmt.Sprintf("%s = ANY($%d)", filter.column, argPos))
+			args = append(args, filter.values)
+			argPos++
+		}
+	}
+	if params.ExcludeRestored {
+		whereClauses = append(whereClauses, "restored_at IS NULL")
+	}
+	if params.TraceID != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("trace_id = $%d", argPos))
+		args = append(args, params.TraceID)
+		argPos++
+	}
+	if params.SpanID != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("span_id = $%d", argPos))
+		args = append(args, params.SpanID)
+		argPos++
+	}
+	for _, f := range params.Attributes {
+		clause, clauseArgs, err := f.clause(argPos)
+		if err != nil {
+			return "", nil, err
+		}
+		whereClauses = append(whereClauses, clause)
+		args = append(args, clauseArgs...)
+		argPos += len(clauseArgs)
+	}
+	if params.Since != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("timestamp >= $%d", argPos))
+		args = append(args, *params.Since)
+		argPos++
+	}
+	if params.Until != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("timestamp <= $%d", argPos))
+		args = append(args, *params.Until)
+		argPos++
+	}
+	if params.After != nil {
+		// Row comparison follows the (timestamp, id) ordering; callers check
+		// CursorCompatible, anything else falls back to descending.
+		op := "<"
+		if desc, ok := keysetDirection(params.Sort); ok && !desc {
+			op = ">"
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("(timestamp, id) %s ($%d, $%d)", op, argPos, argPos+1))
+		args = append(args, params.After.Timestamp, params.After.ID)
+	}
+
+	return strings.Join(whereClauses, " AND "), args, nil
+}
+
+// tsQueryExpr returns the tsquery for a websearch-style query bound at argPos.
+func tsQueryExpr(argPos int) string {
+	return fmt.Sprintf("websearch_to_tsquery('%s', $%d)", textSearchConfig, argPos)
+}
+
+// rankExpr returns the relevance expression with params.Query bound at
+// argPos. Without a query it returns "", which orderByClause rejects.
+func rankExpr(params SearchParams, argPos int) string {
+	if params.Query == "" {
+		return ""
+	}
+	return fmt.Sprintf("ts_rank(search_vector, %s)", tsQueryExpr(argPos))
+}
+
+// Search returns log entries matching filters in SearchParams.
+func (r *Repo) Search(ctx context.Context, params SearchParams) ([]*modelpkg.LogEntry, error) {
+	const maxLimit = 1000
+
+	if params.After != nil && !CursorCompatible(params.Sort) {
+		return nil, ErrCursorSort
+	}
+
+	whereClause, args, err := buildWhere(params)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Search: %w", err)
+	}
+
+	// Ranking and highlighting bind the query once more, but only if used:
+	// Postgres rejects parameters that do not appear in the statement.
+	queryPos := len(args) + 1
+	var headline string
+	if params.Highlight && params.Query != "" {
+		headline = fmt.Sprintf(
+			"ts_headline('%s', message, %s, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')",
+			textSearchConfig, tsQueryExpr(queryPos),
+		)
+	}
+
+	orderBy, err := orderByClause(params.Sort, rankExpr(params, queryPos))
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Search: %w", err)
+	}
+
+	if headline != "" || (params.Query != "" && SortsByRelevance(params.Sort)) {
+		args = append(args, params.Query)
+	}
+	argPos := len(args) + 1
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	offset := params.Offset
+	if offset < 0 || params.After != nil {
+		offset = 0
+	}
+
+	tmplData := struct {
+		Table       string
+		WhereClause string
+		Headline    string
+		OrderBy     string
+		LimitPos    int
+		OffsetPos   int
+	}{
+		Table:       r.tableName(),
+		WhereClause: whereClause,
+		Headline:    headline,
+		OrderBy:     orderBy,
+		LimitPos:    argPos,
+		OffsetPos:   argPos + 1,
+	}
+
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "search", tmplData); err != nil {
+		return nil, fmt.Errorf("Repo.Search: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	args = append(args, limit, offset)
+
+	rows, err := r.pgPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Search: query error: %w", regexError(err))
+	}
+	defer rows.Close()
+
+	var result []*modelpkg.LogEntry
+	for rows.Next() {
+		var e modelpkg.LogEntry
+		targets := scanTargets(&e)
+		if headline != "" {
+			targets = append(targets, &e.Headline)
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return nil, fmt.Errorf("Repo.Search: row scan error: %w", err)
+		}
+		result = append(result, &e)
+	}
+
+	// detect mid-stream / final iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Repo.Search: rows error: %w", regexError(err))
+	}
+
+	return result, nil
+}
+
+// Count returns the exact number of entries matching the filters in
+// SearchParams. Pagination fields (After, Limit, Offset) are ignored.
+func (r *Repo) Count(ctx context.Context, params SearchParams) (int64, error) {
+	params.After = nil
+	whereClause, args, err := buildWhere(params)
+	if err != nil {
+		return 0, fmt.Errorf("Repo.Count: %w", err)
+	}
+
+	tmplData := struct {
+		Table       string
+		WhereClause string
+	}{
+		Table:       r.tableName(),
+		WhereClause: whereClause,
+	}
+
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "count", tmplData); err != nil {
+		return 0, fmt.Errorf("Repo.Count: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	var total int64
+	if err := r.pgPool.QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("Repo.Count: query error: %w", regexError(err))
+	}
+
+	return total, nil
+}
+
+// EstimateCount returns the planner's row estimate for the filters in
+// SearchParams. It avoids scanning the table, so it is cheap on huge tables
+// but only as accurate as the table statistics.
+func (r *Repo) EstimateCount(ctx context.Context, params SearchParams) (int64, error) {
+	params.After = nil
+	whereClause, args, err := buildWhere(params)
+	if err != nil {
+		return 0, fmt.Errorf("Repo.EstimateCount: %w", err)
+	}
+
+	tmplData := struct {
+		Table       string
+		WhereClause string
+	}{
+		Table:       r.tableName(),
+		WhereClause: whereClause,
+	}
+
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "estimate", tmplData); err != nil {
+		return 0, fmt.Errorf("Repo.EstimateCount: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	var raw []byte
+	if err := r.pgPool.QueryRow(ctx, query, args...).Scan(&raw); err != nil {
+		return 0, fmt.Errorf("Repo.EstimateCount: query error: %w", regexError(err))
+	}
+
+	var plan []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plan); err != nil || len(plan) == 0 {
+		return 0, fmt.Errorf("Repo.EstimateCount: unexpected plan output: %s", raw)
+	}
+
+	return int64(plan[0].Plan.PlanRows), nil
+}
+
+// Facets returns the number of entries per distinct value of field for the
+// filters in SearchParams. Pagination fields are ignored. Only fields listed
+// in facetColumns are accepted, others yield ErrUnknownFacet.
+func (r *Repo) Facets(ctx context.Context, params SearchParams, field string) (map[string]int64, error) {
+	column, ok := facetColumns[field]
+	if !ok {
+		return nil, ErrUnknownFacet
+	}
+
+	params.After = nil
+	whereClause, args, err := buildWhere(params)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Facets: %w", err)
+	}
+
+	tmplData := struct {
+		Table       string
+		Column      string
+		WhereClause string
+	}{
+		Table:       r.tableName(),
+		Column:      column,
+		WhereClause: whereClause,
+	}
+
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "facet", tmplData); err != nil {
+		return nil, fmt.Errorf("Repo.Facets: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	rows, err := r.pgPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Facets: query error: %w", regexError(err))
+	}
+	defer rows.Close()
+
+	result := map[string]int64{}
+	for rows.Next() {
+		var value string
+		var n int64
+		if err := rows.Scan(&value, &n); err != nil {
+			return nil, fmt.Errorf("Repo.Facets: row scan error: %w", err)
+		}
+		result[value] = n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Repo.Facets: rows error: %w", regexError(err))
+	}
+
+	return result, nil
+}
+
+// Export calls fn for every entry matching the filters in SearchParams,
+// in search order. Rows are pulled from a server-side cursor in chunks of
+// exportFetchSize, so memory use does not depend on the result size.
+// Limit and Offset are ignored. Returning an error from fn stops the export.
+func (r *Repo) Export(ctx context.Context, params SearchParams, fn func(*modelpkg.LogEntry) error) error {
+	if params.After != nil && !CursorCompatible(params.Sort) {
+		return ErrCursorSort
+	}
+
+	whereClause, args, err := buildWhere(params)
+	if err != nil {
+		return fmt.Errorf("Repo.Export: %w", err)
+	}
+
+	orderBy, err := orderByClause(params.Sort, rankExpr(params, len(args)+1))
+	if err != nil {
+		return fmt.Errorf("Repo.Export: %w", err)
+	}
+	if SortsByRelevance(params.Sort) {
+		args = append(args, params.Query)
+	}
+
+	tmplData := struct {
+		Table       string
+		Cursor      string
+		WhereClause string
+		OrderBy     string
+		FetchSize   int
+	}{
+		Table:       r.tableName(),
+		Cursor:      "logentry_export",
+		WhereClause: whereClause,
+		OrderBy:     orderBy,
+		FetchSize:   exportFetchSize,
+	}
+
+	var declareBuf, fetchBuf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&declareBuf, "exportDeclare", tmplData); err != nil {
+		return fmt.Errorf("Repo.Export: template execution error for declare: %w", err)
+	}
+	if err := queryTmpl.ExecuteTemplate(&fetchBuf, "exportFetch", tmplData); err != nil {
+		return fmt.Errorf("Repo.Export: template execution error for fetch: %w", err)
+	}
+
+	// Cursors only live inside a transaction.
+	tx, err := r.pgPool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("Repo.Export: begin failed: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, declareBuf.String(), args...); err != nil {
+		return fmt.Errorf("Repo.Export: declare cursor failed: %w", regexError(err))
+	}
+
+	fetch := fetchBuf.String()
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("Repo.Export: fetch failed: %w", regexError(err))
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var e modelpkg.LogEntry
+			if err := rows.Scan(scanTargets(&e)...); err != nil {
+				rows.Close()
+				return fmt.Errorf("Repo.Export: row scan error: %w", err)
+			}
+			fetched++
+			if err := fn(&e); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("Repo.Export: rows error: %w", regexError(err))
+		}
+
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
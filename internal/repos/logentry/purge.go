@@ -0,0 +1,246 @@
+package logentry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// purgeWhere builds the WHERE clause of a PurgeFilter. Arguments start at $1.
+func purgeWhere(f PurgeFilter) (string, []any) {
+	clauses := []string{"timestamp < $1"}
+	args := []any{f.Before}
+
+	if !f.KeepRestoredSince.IsZero() {
+		clauses = append(clauses, "(restored_at IS NULL OR restored_at < $"+strconv.Itoa(len(args)+1)+")")
+		args = append(args, f.KeepRestoredSince)
+	}
+
+	scope, scopeArgs := scopeClause(f.Scope, len(args)+1)
+	if scope != "" {
+		clauses = append(clauses, scope)
+		args = append(args, scopeArgs...)
+	}
+
+	for _, except := range f.Except {
+		clause, exceptArgs := scopeClause(except, len(args)+1)
+		if clause == "" {
+			// Excluding everything leaves nothing to purge.
+			return "FALSE", nil
+		}
+		// Entries without a service never match a service scope.
+		if except.Service != "" {
+			clause = "(" + clause + ") IS TRUE"
+		}
+		clauses = append(clauses, "NOT ("+clause+")")
+		args = append(args, exceptArgs...)
+	}
+
+	for _, r := range f.ExceptRanges {
+		pos := len(args) + 1
+		clauses = append(clauses, fmt.Sprintf("NOT (timestamp >= $%d AND timestamp < $%d)", pos, pos+1))
+		args = append(args, r.From, r.To)
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+// scopeClause returns the condition for one scope, or "" if it matches all.
+func scopeClause(s PurgeScope, argPos int) (string, []any) {
+	var parts []string
+	var args []any
+	if s.Service != "" {
+		parts = append(parts, "service = $"+strconv.Itoa(argPos+len(args)))
+		args = append(args, s.Service)
+	}
+	if s.Level != "" {
+		parts = append(parts, "level = $"+strconv.Itoa(argPos+len(args)))
+		args = append(args, s.Level)
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// Purge deletes up to limit entries matching f and evicts them from the
+// cache. It returns the deleted IDs; fewer than limit means f is exhausted.
+func (r *Repo) Purge(ctx context.Context, f PurgeFilter, limit int) ([]int, error) {
+	where, args := purgeWhere(f)
+	ids, err := r.purge(ctx, where, args, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.Purge: %w", err)
+	}
+	return ids, nil
+}
+
+// PurgeRestored deletes up to limit entries restored from the archive
+// before t, i.e. whose restore hold has expired, and evicts them from the
+// cache. It returns the deleted IDs; fewer than limit means none are left.
+func (r *Repo) PurgeRestored(ctx context.Context, t time.Time, limit int) ([]int, error) {
+	ids, err := r.purge(ctx, "restored_at < $1", []any{t}, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Repo.PurgeRestored: %w", err)
+	}
+	return ids, nil
+}
+
+// purge runs the "purge" template for where, whose arguments start at $1.
+func (r *Repo) purge(ctx context.Context, where string, args []any, limit int) ([]int, error) {
+	tmplData := struct {
+		Table       string
+		WhereClause string
+		LimitPos    int
+	}{
+		Table:       r.tableName(),
+		WhereClause: where,
+		LimitPos:    len(args) + 1,
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "purge", tmplData); err != nil {
+		return nil, fmt.Errorf("template execution error: %w", err)
+	}
+	query := buf.String()
+
+	rows, err := r.pgPool.Query(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("delete failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("row scan error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	r.Evict(ctx, ids...)
+
+	return ids, nil
+}
+
+// CountPurgeable returns the number of entries Purge would delete for f.
+func (r *Repo) CountPurgeable(ctx context.Context, f PurgeFilter) (int64, error) {
+	where, args := purgeWhere(f)
+
+	tmplData := struct {
+		Table       string
+		WhereClause string
+	}{
+		Table:       r.tableName(),
+		WhereClause: where,
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "count", tmplData); err != nil {
+		return 0, fmt.Errorf("Repo.CountPurgeable: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	var n int64
+	if err := r.pgPool.QueryRow(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("Repo.CountPurgeable: query failed: %w", err)
+	}
+
+	return n, nil
+}
+
+// Evict removes the cached copies of the given entries, e.g. after they were
+// removed by dropping a partition. Failures are logged, not returned.
+func (r *Repo) Evict(ctx context.Context, ids ...int) {
+	if r.cacheClient == nil || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.cacheKey(id)
+	}
+	if err := r.cacheClient.Del(ctx, keys...); err != nil {
+		log.Printf("[warning] cache del failed for %d keys: %v", len(keys), err)
+	}
+}
+
+// DeleteIDs deletes the given entries and evicts them from the cache. It
+// returns the number of rows deleted; missing IDs are ignored.
+func (r *Repo) DeleteIDs(ctx context.Context, ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "deleteIDs", tmplData); err != nil {
+		return 0, fmt.Errorf("Repo.DeleteIDs: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	tag, err := r.pgPool.Exec(ctx, query, ids)
+	if err != nil {
+		return 0, fmt.Errorf("Repo.DeleteIDs: delete failed: %w", err)
+	}
+
+	r.Evict(ctx, ids...)
+
+	return tag.RowsAffected(), nil
+}
+
+// OldestBefore returns the timestamp of the oldest entry before t, not
+// counting entries restored from the archive. The boolean is false if there
+// is none.
+func (r *Repo) OldestBefore(ctx context.Context, t time.Time) (time.Time, bool, error) {
+	tmplData := struct {
+		Table string
+	}{
+		Table: r.tableName(),
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "oldest", tmplData); err != nil {
+		return time.Time{}, false, fmt.Errorf("Repo.OldestBefore: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	var oldest *time.Time
+	if err := r.pgPool.QueryRow(ctx, query, t).Scan(&oldest); err != nil {
+		return time.Time{}, false, fmt.Errorf("Repo.OldestBefore: query failed: %w", err)
+	}
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+
+	return *oldest, true, nil
+}
+
+// HasRestoredSince reports whether entries in [from, to) were restored from
+// the archive at or after since, i.e. are still within their restore hold.
+func (r *Repo) HasRestoredSince(ctx context.Context, from, to, since time.Time) (bool, error) {
+	tmplData := struct {
+		Table       string
+		WhereClause string
+	}{
+		Table:       r.tableName(),
+		WhereClause: "timestamp >= $1 AND timestamp < $2 AND restored_at >= $3",
+	}
+	var buf bytes.Buffer
+	if err := queryTmpl.ExecuteTemplate(&buf, "count", tmplData); err != nil {
+		return false, fmt.Errorf("Repo.HasRestoredSince: template execution error: %w", err)
+	}
+	query := buf.String()
+
+	var n int64
+	if err := r.pgPool.QueryRow(ctx, query, from, to, since).Scan(&n); err != nil {
+		return false, fmt.Errorf("Repo.HasRestoredSince: query failed: %w", err)
+	}
+
+	return n > 0, nil
+}
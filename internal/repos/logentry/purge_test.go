@@ -0,0 +1,58 @@
+package logentry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPurgeWhere(t *testing.T) {
+	before := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	hold := before.Add(-time.Hour)
+	day := before.Add(-48 * time.Hour)
+
+	tests := []struct {
+		name     string
+		filter   PurgeFilter
+		want     string
+		wantArgs int
+	}{
+		{
+			name:     "before only",
+			filter:   PurgeFilter{Before: before},
+			want:     "timestamp < $1",
+			wantArgs: 1,
+		},
+		{
+			name:     "scope and restore hold",
+			filter:   PurgeFilter{Before: before, KeepRestoredSince: hold, Scope: PurgeScope{Service: "api", Level: "debug"}},
+			want:     "timestamp < $1 AND (restored_at IS NULL OR restored_at < $2) AND service = $3 AND level = $4",
+			wantArgs: 4,
+		},
+		{
+			name:     "except scope",
+			filter:   PurgeFilter{Before: before, Except: []PurgeScope{{Service: "api"}, {Level: "error"}}},
+			want:     "timestamp < $1 AND NOT ((service = $2) IS TRUE) AND NOT (level = $3)",
+			wantArgs: 3,
+		},
+		{
+			name:   "except everything",
+			filter: PurgeFilter{Before: before, Except: []PurgeScope{{}}},
+			want:   "FALSE",
+		},
+		{
+			name:     "except ranges",
+			filter:   PurgeFilter{Before: before, Scope: PurgeScope{Level: "info"}, ExceptRanges: []TimeRange{{From: day, To: day.Add(24 * time.Hour)}}},
+			want:     "timestamp < $1 AND level = $2 AND NOT (timestamp >= $3 AND timestamp < $4)",
+			wantArgs: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := purgeWhere(tt.filter)
+			if got != tt.want || len(args) != tt.wantArgs {
+				t.Errorf("purgeWhere() = %q with %d args, want %q with %d", got, len(args), tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
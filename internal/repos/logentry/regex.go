@@ -0,0 +1,91 @@
+package logentry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sqlStateInvalidRegex is Postgres' invalid_regular_expression error.
+const sqlStateInvalidRegex = "2201B"
+
+// ValidateRegex checks a message regex before it is sent to Postgres. The
+// pattern must compile in Go's syntax and must not use constructs the two
+// dialects disagree on: named groups, Unicode classes (\p, \P), \z, \Q,
+// word boundaries (\b is a backspace in Postgres) and flag groups other
+// than a leading (?i).
+func ValidateRegex(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRegex, err)
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if strings.IndexByte("pPzbBQ", pattern[i]) >= 0 {
+				return fmt.Errorf("%w: \\%c is not supported", ErrInvalidRegex, pattern[i])
+			}
+		case pattern[i] == '[':
+			end, err := bracketEnd(pattern, i)
+			if err != nil {
+				return err
+			}
+			i = end
+		case strings.HasPrefix(pattern[i:], "(?"):
+			rest := pattern[i+2:]
+			switch {
+			case strings.HasPrefix(rest, ":"):
+			case strings.HasPrefix(rest, "P<"), strings.HasPrefix(rest, "<"):
+				return fmt.Errorf("%w: named groups are not supported", ErrInvalidRegex)
+			case i == 0 && strings.HasPrefix(rest, "i)"):
+			default:
+				return fmt.Errorf("%w: flag groups are only supported at the start, as (?i)", ErrInvalidRegex)
+			}
+		}
+	}
+	return nil
+}
+
+// bracketEnd returns the index of the "]" closing the bracket expression
+// that starts at pattern[start]. Escapes inside it follow the same rules
+// as outside, apart from \b, which Go rejects in a class anyway.
+func bracketEnd(pattern string, start int) (int, error) {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++ // a leading ] is literal
+	}
+	for ; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if strings.IndexByte("pPQ", pattern[i]) >= 0 {
+				return 0, fmt.Errorf("%w: \\%c is not supported", ErrInvalidRegex, pattern[i])
+			}
+		case strings.HasPrefix(pattern[i:], "[:"):
+			if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+				i += end + 3
+			}
+		case pattern[i] == ']':
+			return i, nil
+		}
+	}
+	// Unreachable for patterns that compiled.
+	return 0, fmt.Errorf("%w: unterminated bracket expression", ErrInvalidRegex)
+}
+
+// regexError maps Postgres' rejection of a regex that passed
+// ValidateRegex to ErrInvalidRegex, so callers can answer with 400.
+func regexError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateInvalidRegex {
+		return fmt.Errorf("%w: %s", ErrInvalidRegex, pgErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package logentry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: `timeout after \d+ms`},
+		{pattern: `^(?i)error`, wantErr: true},
+		{pattern: `(?i)error`},
+		{pattern: `(?:GET|POST) /api`},
+		{pattern: `[(?]`},
+		{pattern: `[\\b]x`},
+		{pattern: `[]a]`},
+		{pattern: `[^]a]+`},
+		{pattern: `[[:alpha:]]+`},
+		{pattern: `\[(?P<x>)`, wantErr: true},
+		{pattern: `(?P<code>\d+)`, wantErr: true},
+		{pattern: `(?<code>\d+)`, wantErr: true},
+		{pattern: `\pL+`, wantErr: true},
+		{pattern: `[\p{Greek}]`, wantErr: true},
+		{pattern: `end\z`, wantErr: true},
+		{pattern: `\bword\b`, wantErr: true},
+		{pattern: `\Bx`, wantErr: true},
+		{pattern: `\Qa.b\E`, wantErr: true},
+		{pattern: `(?m)^error`, wantErr: true},
+		{pattern: `(?s)a.b`, wantErr: true},
+		{pattern: `(?is)a.b`, wantErr: true},
+		{pattern: `a(?i)b`, wantErr: true},
+		{pattern: `(?i:a)b`, wantErr: true},
+		{pattern: `(unclosed`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := ValidateRegex(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRegex(%q) error = %v, wantErr %t", tt.pattern, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRegex) {
+				t.Errorf("ValidateRegex(%q) error = %v, want ErrInvalidRegex", tt.pattern, err)
+			}
+		})
+	}
+}
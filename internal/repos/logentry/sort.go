@@ -0,0 +1,137 @@
+package logentry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSort is returned for sort specifications with unknown fields or directions.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ErrRelevanceSort is returned when sorting by relevance without a full-text query.
+var ErrRelevanceSort = errors.New("relevance sort requires a full-text query")
+
+// ErrCursorSort is returned when keyset pagination is combined with a sort
+// order it cannot follow.
+var ErrCursorSort = errors.New("cursor pagination requires sorting by timestamp")
+
+// SortField is one key of a search ordering.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumns whitelists the sortable fields and maps them to their column.
+// Levels sort by their numeric severity, not alphabetically. Relevance has
+// no column, it is the rank of the full-text query.
+var sortColumns = map[string]string{
+	"timestamp": "timestamp",
+	"id":        "id",
+	"level":     "severity",
+	"severity":  "severity",
+	"relevance": "",
+}
+
+// defaultSort is the ordering used when SearchParams.Sort is empty.
+var defaultSort = []SortField{{Field: "timestamp", Desc: true}, {Field: "id", Desc: true}}
+
+// ParseSort parses a comma-separated sort specification such as
+// "timestamp:asc,id:asc". The direction defaults to descending.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, dir, _ := strings.Cut(part, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := sortColumns[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidSort, name)
+		}
+		seen[name] = true
+
+		field := SortField{Field: name, Desc: true}
+		switch strings.ToLower(strings.TrimSpace(dir)) {
+		case "", "desc":
+		case "asc":
+			field.Desc = false
+		default:
+			return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidSort, dir)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// effectiveSort returns the ordering actually applied: the default when
+// none is given, and always ending in id so the order is total.
+func effectiveSort(fields []SortField) []SortField {
+	if len(fields) == 0 {
+		return defaultSort
+	}
+	for _, f := range fields {
+		if f.Field == "id" {
+			return fields
+		}
+	}
+	out := make([]SortField, len(fields), len(fields)+1)
+	copy(out, fields)
+	return append(out, SortField{Field: "id", Desc: fields[len(fields)-1].Desc})
+}
+
+// keysetDirection reports whether fields order by (timestamp, id) in a single
+// direction, which is what Cursor encodes, and if so whether it is descending.
+func keysetDirection(fields []SortField) (desc bool, ok bool) {
+	eff := effectiveSort(fields)
+	if len(eff) != 2 || eff[0].Field != "timestamp" || eff[1].Field != "id" || eff[0].Desc != eff[1].Desc {
+		return false, false
+	}
+	return eff[0].Desc, true
+}
+
+// CursorCompatible reports whether keyset pagination can be used with fields.
+func CursorCompatible(fields []SortField) bool {
+	_, ok := keysetDirection(fields)
+	return ok
+}
+
+// SortsByRelevance reports whether fields include the relevance sort.
+func SortsByRelevance(fields []SortField) bool {
+	for _, f := range fields {
+		if f.Field == "relevance" {
+			return true
+		}
+	}
+	return false
+}
+
+// orderByClause renders the ORDER BY list for fields. rankExpr is the SQL
+// used for the relevance field; it is empty when there is no text query.
+func orderByClause(fields []SortField, rankExpr string) (string, error) {
+	eff := effectiveSort(fields)
+	parts := make([]string, len(eff))
+	for i, f := range eff {
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		column := sortColumns[f.Field]
+		if f.Field == "relevance" {
+			if rankExpr == "" {
+				return "", ErrRelevanceSort
+			}
+			column = rankExpr
+		}
+		parts[i] = column + " " + dir
+	}
+	return strings.Join(parts, ", "), nil
+}
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxAgeDays is the longest age in days a time.Duration can hold.
+const maxAgeDays = int64(math.MaxInt64 / (24 * time.Hour))
+
+// ParseAge parses a positive age such as "36h", "30d" or "7y". Besides Go
+// durations it accepts whole days (d) and years (y, 365 days).
+func ParseAge(raw string) (time.Duration, error) {
+	var age time.Duration
+	switch {
+	case strings.HasSuffix(raw, "d"), strings.HasSuffix(raw, "y"):
+		days, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", raw)
+		}
+		if days <= 0 {
+			return 0, fmt.Errorf("age must be positive, got %q", raw)
+		}
+		if strings.HasSuffix(raw, "y") {
+			if days > maxAgeDays/365 {
+				return 0, fmt.Errorf("age %q is too long", raw)
+			}
+			days *= 365
+		}
+		if days > maxAgeDays {
+			return 0, fmt.Errorf("age %q is too long", raw)
+		}
+		age = time.Duration(days) * 24 * time.Hour
+	default:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", raw)
+		}
+		age = d
+	}
+
+	if age <= 0 {
+		return 0, fmt.Errorf("age must be positive, got %q", raw)
+	}
+	return age, nil
+}
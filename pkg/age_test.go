@@ -0,0 +1,43 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{raw: "36h", want: 36 * time.Hour},
+		{raw: "30d", want: 30 * day},
+		{raw: "7y", want: 7 * 365 * day},
+		{raw: "292y", want: 292 * 365 * day},
+		{raw: "106751d", want: 106751 * day},
+		{raw: "293y", wantErr: true},
+		{raw: "600y", wantErr: true},
+		{raw: "106752d", wantErr: true},
+		{raw: "99999999999999999999d", wantErr: true},
+		{raw: "0d", wantErr: true},
+		{raw: "-1y", wantErr: true},
+		{raw: "-5m", wantErr: true},
+		{raw: "d", wantErr: true},
+		{raw: "1.5d", wantErr: true},
+		{raw: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseAge(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, wantErr %t", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAge(%q) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}